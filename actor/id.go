@@ -0,0 +1,116 @@
+package actor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// separator splits the type and the id in a flipper id.
+const separator = ";"
+
+// ID is an actor identified by its flipper id.
+type ID string
+
+// FlipperID returns the flipper id.
+// It satisfies the Actor interface.
+func (id ID) FlipperID() string {
+	return string(id)
+}
+
+// New returns an actor with a flipper id in the format "Type;id",
+// the same format the Ruby gem uses by default, like "User;1".
+// typ must be the name of the Ruby class to match actors enabled from Ruby.
+func New(typ, id string) ID {
+	return ID(typ + separator + id)
+}
+
+// FromInt returns an actor for an integer id, like "User;1".
+func FromInt(typ string, id int64) ID {
+	return New(typ, strconv.FormatInt(id, 10))
+}
+
+// FromUint returns an actor for an unsigned integer id, like "User;1".
+func FromUint(typ string, id uint64) ID {
+	return New(typ, strconv.FormatUint(id, 10))
+}
+
+// FromUUID returns an actor for an UUID id, formatted in lower case with hyphens,
+// like "User;6ba7b810-9dad-11d1-80b4-00c04fd430c8".
+// Most UUID packages define their UUID type as a [16]byte and can be converted.
+func FromUUID(typ string, id [16]byte) ID {
+	return New(typ, formatUUID(id))
+}
+
+// Model adapts a struct to the Actor interface using
+// the name of its type and the value of its ID field, without reflection:
+//
+//	actor.Model{Type: "User", ID: user.ID}
+//
+// ID can be a string, any integer type, a fmt.Stringer or a [16]byte UUID,
+// including named types like the UUID types in most UUID packages.
+// Other values are formatted with fmt.Sprint.
+type Model struct {
+	Type string
+	ID   interface{}
+}
+
+// FlipperID returns the flipper id in the format "Type;id".
+// It satisfies the Actor interface.
+func (m Model) FlipperID() string {
+	return string(New(m.Type, formatID(m.ID)))
+}
+
+func formatID(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case [16]byte:
+		return formatUUID(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		// named UUID types without a String method, like `type UUID [16]byte`.
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Array && rv.Type().ConvertibleTo(uuidType) {
+			return formatUUID(rv.Convert(uuidType).Interface().([16]byte))
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+var uuidType = reflect.TypeOf([16]byte{})
+
+func formatUUID(id [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[0:8], id[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], id[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], id[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], id[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], id[10:])
+	return string(b[:])
+}
@@ -0,0 +1,58 @@
+package actor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type uuid [16]byte
+
+type slug string
+
+func (s slug) String() string {
+	return "slug-" + string(s)
+}
+
+func TestID(t *testing.T) {
+	u := uuid{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	t.Run("helpers", func(t *testing.T) {
+		require.Equal(t, "User;abc", New("User", "abc").FlipperID())
+		require.Equal(t, "User;1", FromInt("User", 1).FlipperID())
+		require.Equal(t, "User;-1", FromInt("User", -1).FlipperID())
+		require.Equal(t, "User;18446744073709551615", FromUint("User", 1<<64-1).FlipperID())
+		require.Equal(t, "User;6ba7b810-9dad-11d1-80b4-00c04fd430c8", FromUUID("User", u).FlipperID())
+	})
+
+	t.Run("model", func(t *testing.T) {
+		tests := []struct {
+			id       interface{}
+			expected string
+		}{
+			{"abc", "Org;abc"},
+			{int(1), "Org;1"},
+			{int8(-8), "Org;-8"},
+			{int16(16), "Org;16"},
+			{int32(32), "Org;32"},
+			{int64(64), "Org;64"},
+			{uint(1), "Org;1"},
+			{uint8(8), "Org;8"},
+			{uint16(16), "Org;16"},
+			{uint32(32), "Org;32"},
+			{uint64(64), "Org;64"},
+			{[16]byte(u), "Org;6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+			{u, "Org;6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+			{slug("acme"), "Org;slug-acme"},
+			{1.5, "Org;1.5"},
+		}
+
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%T", test.id), func(t *testing.T) {
+				var a Actor = Model{Type: "Org", ID: test.id}
+				require.Equal(t, test.expected, a.FlipperID())
+			})
+		}
+	})
+}
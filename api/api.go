@@ -0,0 +1,382 @@
+// Package api serves features over HTTP with the same routes and payloads
+// as the flipper-api Ruby gem, so clients of that API can use Go services.
+//
+// The handler expects paths relative to where it's mounted, use http.StripPrefix
+// to serve it under a prefix:
+//
+//	http.Handle("/flipper/api/", http.StripPrefix("/flipper/api", api.NewHandler(c)))
+//
+// These are the routes:
+//
+//	GET    /features                             list every feature
+//	POST   /features                             add a feature, with the parameter name
+//	GET    /features/:name                       show a feature
+//	DELETE /features/:name                       remove a feature
+//	DELETE /features/:name/clear                 disable every gate for a feature
+//	POST   /features/:name/boolean               enable a feature
+//	DELETE /features/:name/boolean               disable a feature
+//	POST   /features/:name/actors                enable a feature for an actor, with the parameter flipper_id
+//	DELETE /features/:name/actors                disable a feature for an actor, with the parameter flipper_id
+//	POST   /features/:name/groups                enable a feature for a group, with the parameter name
+//	DELETE /features/:name/groups                disable a feature for a group, with the parameter name
+//	POST   /features/:name/percentage_of_actors  enable a feature for a percentage of actors, with the parameter percentage
+//	DELETE /features/:name/percentage_of_actors  disable the percentage of actors
+//	POST   /features/:name/percentage_of_time    enable a feature for a percentage of time, with the parameter percentage
+//	DELETE /features/:name/percentage_of_time    disable the percentage of time
+//	POST   /features/:name/excluded_actors       exclude an actor from a feature, with the parameter flipper_id
+//	DELETE /features/:name/excluded_actors       stop excluding an actor, with the parameter flipper_id
+//	POST   /features/:name/schedule              enable a feature during a time window, with the parameters start and end
+//	DELETE /features/:name/schedule              disable the time window
+//	POST   /features/:name/ramp                  enable a feature for a growing percentage of actors, with the parameters start, duration, from and to
+//	DELETE /features/:name/ramp                  disable the ramp
+//
+// Times are in RFC 3339 format. The schedule parameters are optional,
+// an open ended window omits a time. Ramp durations are in the format
+// of time.ParseDuration, and its percentages are integers.
+//
+// Parameters can be sent in the query string, as a form or as a JSON object.
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/gates"
+)
+
+// maxBodySize limits the size of request bodies, parameters are small.
+const maxBodySize = 1 << 20
+
+// Handler is an http.Handler that serves the flipper-api routes.
+type Handler struct {
+	client *client.Client
+}
+
+// NewHandler initializes a handler that manages features with a client.
+// The client's driver must implement the driver.Lister interface.
+func NewHandler(c *client.Client) *Handler {
+	return &Handler{client: c}
+}
+
+// ServeHTTP routes a request to its action.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "features" || len(parts) > 3 || (len(parts) > 1 && parts[1] == "") {
+		http.NotFound(w, r)
+		return
+	}
+
+	params, err := parseParams(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, Error{Message: err.Error()})
+		return
+	}
+
+	switch len(parts) {
+	case 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.listFeatures(w)
+		case http.MethodPost:
+			h.addFeature(w, params)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case 2:
+		name := parts[1]
+		switch r.Method {
+		case http.MethodGet:
+			h.showFeature(w, name)
+		case http.MethodDelete:
+			h.respond(w, "", http.StatusNoContent, h.client.Remove(name))
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case 3:
+		name, gate := parts[1], parts[2]
+		if gate == "clear" {
+			if r.Method != http.MethodDelete {
+				methodNotAllowed(w, http.MethodDelete)
+				return
+			}
+			h.respond(w, "", http.StatusNoContent, h.client.Clear(name))
+			return
+		}
+
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			methodNotAllowed(w, http.MethodPost, http.MethodDelete)
+			return
+		}
+		h.updateGate(w, name, gate, r.Method == http.MethodPost, params)
+	}
+}
+
+func (h *Handler) listFeatures(w http.ResponseWriter) {
+	features, err := h.client.Features()
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	list := Features{Features: make([]Feature, 0, len(features))}
+	for _, f := range features {
+		s, err := h.client.Feature(f.Name)
+		if err != nil {
+			writeInternalError(w, err)
+			return
+		}
+		list.Features = append(list.Features, newFeature(s))
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) addFeature(w http.ResponseWriter, params url.Values) {
+	name := params.Get("name")
+	if name == "" {
+		writeError(w, http.StatusUnprocessableEntity, ErrNameInvalid)
+		return
+	}
+
+	h.respond(w, name, http.StatusOK, h.client.Add(name))
+}
+
+func (h *Handler) showFeature(w http.ResponseWriter, name string) {
+	features, err := h.client.Features()
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	for _, f := range features {
+		if f.Name == name {
+			h.respond(w, name, http.StatusOK, nil)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, ErrFeatureNotFound)
+}
+
+func (h *Handler) updateGate(w http.ResponseWriter, name, gate string, enable bool, params url.Values) {
+	var err error
+
+	switch gates.GateKey(gate) {
+	case gates.BoolGateKey:
+		if enable {
+			err = h.client.Enable(name)
+		} else {
+			err = h.client.Disable(name)
+		}
+	case gates.ActorGateKey:
+		id := params.Get("flipper_id")
+		if id == "" {
+			writeError(w, http.StatusUnprocessableEntity, ErrFlipperIDInvalid)
+			return
+		}
+		if enable {
+			err = h.client.EnableForActors(name, actor.ID(id))
+		} else {
+			err = h.client.DisableForActors(name, actor.ID(id))
+		}
+	case gates.ExclusionGateKey:
+		id := params.Get("flipper_id")
+		if id == "" {
+			writeError(w, http.StatusUnprocessableEntity, ErrFlipperIDInvalid)
+			return
+		}
+		if enable {
+			err = h.client.ExcludeActors(name, actor.ID(id))
+		} else {
+			err = h.client.RemoveExcludedActors(name, actor.ID(id))
+		}
+	case gates.GroupGateKey:
+		group := params.Get("name")
+		if !gates.IsGroupRegistered(group) {
+			writeError(w, http.StatusNotFound, ErrGroupNotRegistered)
+			return
+		}
+		if enable {
+			err = h.client.EnableForGroups(name, group)
+		} else {
+			err = h.client.DisableForGroups(name, group)
+		}
+	case gates.PercentageOfActorsGateKey:
+		if !enable {
+			err = h.client.DisableForPercentageOfActors(name)
+			break
+		}
+		p, ok := percentage(params.Get("percentage"))
+		if !ok || p != math.Trunc(p) {
+			writeError(w, http.StatusUnprocessableEntity, ErrPercentageInvalid)
+			return
+		}
+		err = h.client.EnableForPercentageOfActors(name, int(p))
+	case gates.PercentageOfTimeGateKey:
+		if !enable {
+			err = h.client.DisableForPercentageOfTime(name)
+			break
+		}
+		p, ok := percentage(params.Get("percentage"))
+		if !ok {
+			writeError(w, http.StatusUnprocessableEntity, ErrPercentageInvalid)
+			return
+		}
+		err = h.client.EnableForFractionalPercentageOfTime(name, p)
+	case gates.ScheduleGateKey:
+		if !enable {
+			err = h.client.DisableSchedule(name)
+			break
+		}
+		start, ok := timeParam(params.Get("start"))
+		end, endOK := timeParam(params.Get("end"))
+		if !ok || !endOK {
+			writeError(w, http.StatusUnprocessableEntity, Error{Message: "Schedule times must be in RFC 3339 format."})
+			return
+		}
+		if !start.IsZero() && !end.IsZero() && !end.After(start) {
+			writeError(w, http.StatusUnprocessableEntity, Error{Message: "Schedule must end after it starts."})
+			return
+		}
+		err = h.client.EnableBetween(name, start, end)
+	case gates.RampGateKey:
+		if !enable {
+			err = h.client.DisableRamp(name)
+			break
+		}
+		start, ok := timeParam(params.Get("start"))
+		if !ok {
+			writeError(w, http.StatusUnprocessableEntity, Error{Message: "Ramp start must be in RFC 3339 format."})
+			return
+		}
+		duration, derr := time.ParseDuration(params.Get("duration"))
+		if derr != nil || duration < 0 {
+			writeError(w, http.StatusUnprocessableEntity, Error{Message: "Ramp duration must be a positive duration."})
+			return
+		}
+		from, fromOK := percentage(params.Get("from"))
+		to, toOK := percentage(params.Get("to"))
+		if !fromOK || !toOK || from != math.Trunc(from) || to != math.Trunc(to) || from > to {
+			writeError(w, http.StatusUnprocessableEntity, Error{Message: "Ramp percentages must be integers that grow between 0 and 100."})
+			return
+		}
+		err = h.client.EnableRamp(name, start, duration, int(from), int(to))
+	default:
+		writeError(w, http.StatusNotFound, Error{Message: "Gate not found."})
+		return
+	}
+
+	h.respond(w, name, http.StatusOK, err)
+}
+
+// respond writes a feature, or an empty response if there's no feature name.
+func (h *Handler) respond(w http.ResponseWriter, name string, status int, err error) {
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	if name == "" {
+		w.WriteHeader(status)
+		return
+	}
+
+	s, err := h.client.Feature(name)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+	writeJSON(w, status, newFeature(s))
+}
+
+// parseParams reads the parameters from the query string and from the body,
+// for any request method.
+func parseParams(w http.ResponseWriter, r *http.Request) (url.Values, error) {
+	params := r.URL.Query()
+	if r.Body == nil {
+		return params, nil
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ct {
+	case "application/json":
+		var values map[string]interface{}
+		if err := json.Unmarshal(body, &values); err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			switch t := v.(type) {
+			case string:
+				params.Set(k, t)
+			case float64:
+				params.Set(k, strconv.FormatFloat(t, 'f', -1, 64))
+			case bool:
+				params.Set(k, strconv.FormatBool(t))
+			}
+		}
+	case "application/x-www-form-urlencoded", "":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			params[k] = v
+		}
+	}
+
+	return params, nil
+}
+
+// percentage parses a percentage between 0 and 100.
+func percentage(v string) (float64, bool) {
+	p, err := strconv.ParseFloat(v, 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, false
+	}
+	return p, true
+}
+
+// timeParam parses a time in RFC 3339 format, empty values are zero times.
+func timeParam(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	return t, err == nil
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, Error{Message: "Method not allowed."})
+}
+
+func writeInternalError(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusInternalServerError, Error{Message: err.Error()})
+}
+
+func writeError(w http.ResponseWriter, status int, e Error) {
+	writeJSON(w, status, e)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
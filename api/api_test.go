@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	status int
+	body   string
+}
+
+func TestHandler(t *testing.T) {
+	gates.RegisterGroup("api_admins", func(a actor.Actor) bool {
+		return strings.HasPrefix(a.FlipperID(), "Admin;")
+	})
+
+	server := httptest.NewServer(http.StripPrefix("/flipper/api", NewHandler(client.NewClient(memory.NewDriver()))))
+	defer server.Close()
+
+	do := func(method, path, contentType, body string) response {
+		req, err := http.NewRequest(method, server.URL+"/flipper/api"+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return response{status: res.StatusCode, body: string(b)}
+	}
+
+	form := func(method, path string, values url.Values) response {
+		return do(method, path, "application/x-www-form-urlencoded", values.Encode())
+	}
+
+	t.Run("features", func(t *testing.T) {
+		r := do("GET", "/features", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		require.JSONEq(t, `{"features":[]}`, r.body)
+
+		r = form("POST", "/features", url.Values{"name": {"search"}})
+		require.Equal(t, http.StatusOK, r.status)
+		require.JSONEq(t, `{
+			"key": "search",
+			"state": "off",
+			"gates": [
+				{"key": "boolean", "name": "boolean", "value": false},
+				{"key": "actors", "name": "actor", "value": []},
+				{"key": "percentage_of_actors", "name": "percentage_of_actors", "value": 0},
+				{"key": "percentage_of_time", "name": "percentage_of_time", "value": 0},
+				{"key": "groups", "name": "group", "value": []}
+			]
+		}`, r.body)
+
+		r = do("GET", "/features/search", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+
+		r = do("GET", "/features", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+
+		var list Features
+		require.NoError(t, json.Unmarshal([]byte(r.body), &list))
+		require.Len(t, list.Features, 1)
+		require.Equal(t, "search", list.Features[0].Key)
+
+		r = do("DELETE", "/features/search", "", "")
+		require.Equal(t, http.StatusNoContent, r.status)
+
+		r = do("GET", "/features/search", "", "")
+		require.Equal(t, http.StatusNotFound, r.status)
+		require.JSONEq(t, `{
+			"code": 1,
+			"message": "Feature not found.",
+			"more_info": "https://github.com/jnunemaker/flipper/tree/master/docs/api#error-code-reference"
+		}`, r.body)
+	})
+
+	t.Run("gates", func(t *testing.T) {
+		r := do("POST", "/features/search/boolean", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		require.Contains(t, r.body, `"state":"on"`)
+
+		r = do("DELETE", "/features/search/boolean", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		require.Contains(t, r.body, `"state":"off"`)
+
+		r = form("POST", "/features/search/actors", url.Values{"flipper_id": {"User;1"}})
+		require.Equal(t, http.StatusOK, r.status)
+		r = do("POST", "/features/search/actors", "application/json", `{"flipper_id": "User;2"}`)
+		require.Equal(t, http.StatusOK, r.status)
+		r = form("DELETE", "/features/search/actors", url.Values{"flipper_id": {"User;1"}})
+		require.Equal(t, http.StatusOK, r.status)
+
+		r = form("POST", "/features/search/groups", url.Values{"name": {"api_admins"}})
+		require.Equal(t, http.StatusOK, r.status)
+		r = do("POST", "/features/search/percentage_of_actors", "application/json", `{"percentage": 25}`)
+		require.Equal(t, http.StatusOK, r.status)
+		r = do("POST", "/features/search/percentage_of_time?percentage=12.5", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+
+		var f Feature
+		require.NoError(t, json.Unmarshal([]byte(r.body), &f))
+		require.Equal(t, Feature{
+			Key:   "search",
+			State: "conditional",
+			Gates: []Gate{
+				{Key: "boolean", Name: "boolean", Value: false},
+				{Key: "actors", Name: "actor", Value: []interface{}{"User;2"}},
+				{Key: "percentage_of_actors", Name: "percentage_of_actors", Value: float64(25)},
+				{Key: "percentage_of_time", Name: "percentage_of_time", Value: 12.5},
+				{Key: "groups", Name: "group", Value: []interface{}{"api_admins"}},
+			},
+		}, f)
+
+		for _, path := range []string{"/features/search/percentage_of_actors", "/features/search/percentage_of_time"} {
+			r = do("DELETE", path, "", "")
+			require.Equal(t, http.StatusOK, r.status)
+		}
+		r = form("DELETE", "/features/search/groups", url.Values{"name": {"api_admins"}})
+		require.Equal(t, http.StatusOK, r.status)
+		r = do("DELETE", "/features/search/actors?flipper_id=User%3B2", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		require.Contains(t, r.body, `"state":"off"`)
+
+		r = do("POST", "/features/search/boolean", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		r = do("DELETE", "/features/search/clear", "", "")
+		require.Equal(t, http.StatusNoContent, r.status)
+		r = do("GET", "/features/search", "", "")
+		require.Equal(t, http.StatusOK, r.status)
+		require.Contains(t, r.body, `"state":"off"`)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			method, path, body string
+			status             int
+			err                Error
+		}{
+			{"POST", "/features", "", http.StatusUnprocessableEntity, ErrNameInvalid},
+			{"POST", "/features/search/actors", "", http.StatusUnprocessableEntity, ErrFlipperIDInvalid},
+			{"DELETE", "/features/search/actors", "flipper_id=", http.StatusUnprocessableEntity, ErrFlipperIDInvalid},
+			{"POST", "/features/search/excluded_actors", "", http.StatusUnprocessableEntity, ErrFlipperIDInvalid},
+			{"POST", "/features/search/schedule", "start=tomorrow", http.StatusUnprocessableEntity, Error{Message: "Schedule times must be in RFC 3339 format."}},
+			{"POST", "/features/search/schedule", "start=2018-11-02T00:00:00Z&end=2018-11-01T00:00:00Z", http.StatusUnprocessableEntity, Error{Message: "Schedule must end after it starts."}},
+			{"POST", "/features/search/ramp", "start=tomorrow&duration=1h&from=0&to=10", http.StatusUnprocessableEntity, Error{Message: "Ramp start must be in RFC 3339 format."}},
+			{"POST", "/features/search/ramp", "duration=soon&from=0&to=10", http.StatusUnprocessableEntity, Error{Message: "Ramp duration must be a positive duration."}},
+			{"POST", "/features/search/ramp", "duration=1h&from=50&to=10", http.StatusUnprocessableEntity, Error{Message: "Ramp percentages must be integers that grow between 0 and 100."}},
+			{"POST", "/features/search/ramp", "duration=1h&from=0.5&to=10", http.StatusUnprocessableEntity, Error{Message: "Ramp percentages must be integers that grow between 0 and 100."}},
+			{"POST", "/features/search/groups", "name=unknown", http.StatusNotFound, ErrGroupNotRegistered},
+			{"POST", "/features/search/percentage_of_actors", "percentage=12.5", http.StatusUnprocessableEntity, ErrPercentageInvalid},
+			{"POST", "/features/search/percentage_of_actors", "percentage=-1", http.StatusUnprocessableEntity, ErrPercentageInvalid},
+			{"POST", "/features/search/percentage_of_time", "percentage=101", http.StatusUnprocessableEntity, ErrPercentageInvalid},
+			{"POST", "/features/search/percentage_of_time", "percentage=lots", http.StatusUnprocessableEntity, ErrPercentageInvalid},
+			{"POST", "/features/search/percentage_of_time", "", http.StatusUnprocessableEntity, ErrPercentageInvalid},
+		}
+
+		for _, test := range tests {
+			r := do(test.method, test.path, "application/x-www-form-urlencoded", test.body)
+			require.Equal(t, test.status, r.status, test.path)
+
+			var e Error
+			require.NoError(t, json.Unmarshal([]byte(r.body), &e))
+			require.Equal(t, test.err, e)
+		}
+
+		r := do("POST", "/features/search/actors", "application/json", `{"flipper_id":`)
+		require.Equal(t, http.StatusBadRequest, r.status)
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		tests := []struct {
+			method, path string
+			status       int
+		}{
+			{"GET", "/", http.StatusNotFound},
+			{"GET", "/actors", http.StatusNotFound},
+			{"GET", "/features//boolean", http.StatusNotFound},
+			{"GET", "/features/search/boolean/extra", http.StatusNotFound},
+			{"POST", "/features/search/unknown", http.StatusNotFound},
+			{"PUT", "/features", http.StatusMethodNotAllowed},
+			{"POST", "/features/search", http.StatusMethodNotAllowed},
+			{"GET", "/features/search/boolean", http.StatusMethodNotAllowed},
+			{"POST", "/features/search/clear", http.StatusMethodNotAllowed},
+		}
+
+		for _, test := range tests {
+			r := do(test.method, test.path, "", "")
+			require.Equal(t, test.status, r.status, test.path)
+		}
+	})
+}
+
+func TestNewFeature(t *testing.T) {
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	f := newFeature(client.FeatureState{
+		Name:  "launch",
+		State: client.StateConditional,
+		GateValues: client.GateValues{
+			Schedule:       &client.Schedule{Start: start},
+			Ramp:           &client.Ramp{Duration: time.Hour, From: 1, To: 10},
+			ExcludedActors: []string{"User;1"},
+		},
+	})
+
+	require.Len(t, f.Gates, 8)
+	require.Equal(t, Gate{
+		Key:   "schedule",
+		Name:  "schedule",
+		Value: map[string]string{"start": "2018-11-01T00:00:00Z"},
+	}, f.Gates[5])
+	require.Equal(t, Gate{
+		Key:   "ramp",
+		Name:  "ramp",
+		Value: map[string]interface{}{"duration": "1h0m0s", "from": 1, "to": 10},
+	}, f.Gates[6])
+	require.Equal(t, Gate{
+		Key:   "excluded_actors",
+		Name:  "excluded_actors",
+		Value: []string{"User;1"},
+	}, f.Gates[7])
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/client"
+)
+
+// ActorFunc returns the actor that makes a request,
+// or nil when the request is anonymous.
+type ActorFunc func(r *http.Request) actor.Actor
+
+// EvaluateHandler is an http.Handler that tells frontend clients
+// which features are enabled for the actor that makes a request.
+// It responds to GET requests with a JSON object that maps
+// feature names to their state:
+//
+//	{"checkout": false, "search": true}
+//
+// Only the features in the allow-list are evaluated, so clients
+// can't learn about other features, allowed features that
+// don't exist are disabled. Responses have an ETag, and
+// requests with a matching If-None-Match header get a 304 response.
+type EvaluateHandler struct {
+	client   *client.Client
+	actorFor ActorFunc
+	allowed  []string
+}
+
+// NewEvaluateHandler initializes a handler that evaluates the allowed features
+// for the actors returned by actorFor. A nil actorFor evaluates features globally.
+func NewEvaluateHandler(c *client.Client, actorFor ActorFunc, allowed ...string) *EvaluateHandler {
+	return &EvaluateHandler{client: c, actorFor: actorFor, allowed: allowed}
+}
+
+// ServeHTTP evaluates the features for a request.
+func (h *EvaluateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, http.MethodGet, http.MethodHead)
+		return
+	}
+
+	var actors []actor.Actor
+	if h.actorFor != nil {
+		if a := h.actorFor(r); a != nil {
+			actors = append(actors, a)
+		}
+	}
+
+	// the allow-list is checked directly instead of filtering Client.EvaluateAll,
+	// so the handler only reads the allowed features and it works
+	// with drivers that can't list features.
+	enabled, err := h.client.IsEnabledMultiContext(r.Context(), h.allowed, actors...)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	// json.Marshal sorts map keys, equal results have equal bodies.
+	body, err := json.Marshal(enabled)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	// responses depend on the actor, browsers must check they are fresh.
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// etagMatches checks if an If-None-Match header includes an ETag,
+// using the weak comparison.
+func etagMatches(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateHandler(t *testing.T) {
+	c := client.NewClient(memory.NewDriver())
+	require.NoError(t, c.Enable("search"))
+	require.NoError(t, c.EnableForActors("checkout", actor.ID("User;1")))
+	require.NoError(t, c.Enable("internal"))
+
+	actorFor := func(r *http.Request) actor.Actor {
+		if id := r.Header.Get("X-User"); id != "" {
+			return actor.ID(id)
+		}
+		return nil
+	}
+	h := NewEvaluateHandler(c, actorFor, "search", "checkout", "signup")
+
+	serve := func(method, user, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/features", nil)
+		if user != "" {
+			r.Header.Set("X-User", user)
+		}
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("actors", func(t *testing.T) {
+		w := serve("GET", "User;1", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+		require.Equal(t, `{"checkout":true,"search":true,"signup":false}`, w.Body.String())
+
+		w = serve("GET", "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `{"checkout":false,"search":true,"signup":false}`, w.Body.String())
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		w := serve("GET", "User;1", "")
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		require.Equal(t, etag, serve("GET", "User;1", "").Header().Get("ETag"))
+		require.NotEqual(t, etag, serve("GET", "User;2", "").Header().Get("ETag"))
+
+		for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			w = serve("GET", "User;1", header)
+			require.Equal(t, http.StatusNotModified, w.Code, header)
+			require.Empty(t, w.Body.String())
+		}
+
+		w = serve("GET", "User;2", etag)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		require.NoError(t, c.Disable("search"))
+		w = serve("GET", "User;1", etag)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `{"checkout":true,"search":false,"signup":false}`, w.Body.String())
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		w := serve("HEAD", "User;1", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotEmpty(t, w.Header().Get("ETag"))
+		require.Empty(t, w.Body.String())
+
+		w = serve("POST", "User;1", "")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"time"
+
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/gates"
+)
+
+// moreInfo is the documentation for the error codes.
+const moreInfo = "https://github.com/jnunemaker/flipper/tree/master/docs/api#error-code-reference"
+
+// Errors returned by the API, with the same codes and messages as the flipper-api gem.
+var (
+	ErrFeatureNotFound    = Error{Code: 1, Message: "Feature not found.", MoreInfo: moreInfo}
+	ErrGroupNotRegistered = Error{Code: 2, Message: "Group not registered.", MoreInfo: moreInfo}
+	ErrPercentageInvalid  = Error{Code: 3, Message: "Percentage must be a positive number less than or equal to 100.", MoreInfo: moreInfo}
+	ErrFlipperIDInvalid   = Error{Code: 4, Message: "Required parameter flipper_id is missing.", MoreInfo: moreInfo}
+	ErrNameInvalid        = Error{Code: 5, Message: "Required parameter name is missing.", MoreInfo: moreInfo}
+)
+
+// Error is the payload of error responses.
+// Errors that are not defined by the flipper-api gem don't have a code.
+type Error struct {
+	Code     int    `json:"code,omitempty"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info,omitempty"`
+}
+
+// Error returns the error message.
+// It satisfies the error interface.
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Features is the payload of the list of features.
+type Features struct {
+	Features []Feature `json:"features"`
+}
+
+// Feature is the payload of a feature.
+type Feature struct {
+	Key   string `json:"key"`
+	State string `json:"state"`
+	Gates []Gate `json:"gates"`
+}
+
+// Gate is the payload of a feature's gate.
+// Value is a bool for the boolean gate, a list of strings for
+// the actors and groups gates, and a number for the percentage gates.
+// The schedule gate's value is an object with its start and end
+// times in RFC 3339 format, open ended windows omit a time.
+// The ramp gate's value is an object with its start time, its duration
+// in the format of time.ParseDuration, and the from and to percentages.
+// The excluded actors gate's value is a list of strings, like the actors gate.
+type Gate struct {
+	Key   string      `json:"key"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// newFeature builds the payload of a feature,
+// with the gates in the same order the Ruby gem uses.
+// Gates that the Ruby gem doesn't have go after them,
+// only when they are set.
+func newFeature(s client.FeatureState) Feature {
+	actors, groups := s.Actors, s.Groups
+	if actors == nil {
+		actors = []string{}
+	}
+	if groups == nil {
+		groups = []string{}
+	}
+
+	f := Feature{
+		Key:   s.Name,
+		State: string(s.State),
+		Gates: []Gate{
+			{Key: string(gates.BoolGateKey), Name: "boolean", Value: s.Boolean},
+			{Key: string(gates.ActorGateKey), Name: "actor", Value: actors},
+			{Key: string(gates.PercentageOfActorsGateKey), Name: "percentage_of_actors", Value: s.PercentageOfActors},
+			{Key: string(gates.PercentageOfTimeGateKey), Name: "percentage_of_time", Value: s.PercentageOfTime},
+			{Key: string(gates.GroupGateKey), Name: "group", Value: groups},
+		},
+	}
+
+	if s.Schedule != nil {
+		window := make(map[string]string)
+		if !s.Schedule.Start.IsZero() {
+			window["start"] = s.Schedule.Start.UTC().Format(time.RFC3339Nano)
+		}
+		if !s.Schedule.End.IsZero() {
+			window["end"] = s.Schedule.End.UTC().Format(time.RFC3339Nano)
+		}
+		f.Gates = append(f.Gates, Gate{Key: string(gates.ScheduleGateKey), Name: "schedule", Value: window})
+	}
+
+	if s.Ramp != nil {
+		ramp := map[string]interface{}{
+			"duration": s.Ramp.Duration.String(),
+			"from":     s.Ramp.From,
+			"to":       s.Ramp.To,
+		}
+		if !s.Ramp.Start.IsZero() {
+			ramp["start"] = s.Ramp.Start.UTC().Format(time.RFC3339Nano)
+		}
+		f.Gates = append(f.Gates, Gate{Key: string(gates.RampGateKey), Name: "ramp", Value: ramp})
+	}
+
+	if len(s.ExcludedActors) > 0 {
+		f.Gates = append(f.Gates, Gate{Key: string(gates.ExclusionGateKey), Name: "excluded_actors", Value: s.ExcludedActors})
+	}
+
+	return f
+}
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"context"
 	"errors"
+	"sort"
+	"time"
 
 	"github.com/calavera/go-flipper/actor"
 	"github.com/calavera/go-flipper/driver"
@@ -9,33 +12,49 @@ import (
 	"github.com/calavera/go-flipper/gates"
 )
 
+// ErrListingNotSupported is returned when a client's driver
+// doesn't implement the driver.Lister interface.
+var ErrListingNotSupported = driver.ErrListingNotSupported
+
 var (
 	globalChecks = []gates.GateKey{
 		gates.BoolGateKey,
 		gates.PercentageOfTimeGateKey,
+		gates.ScheduleGateKey,
 	}
 
 	actorChecks = []gates.GateKey{
+		gates.ExclusionGateKey,
 		gates.BoolGateKey,
 		gates.ActorGateKey,
 		gates.GroupGateKey,
 		gates.PercentageOfActorsGateKey,
 		gates.PercentageOfTimeGateKey,
+		gates.ScheduleGateKey,
+		gates.RampGateKey,
 	}
 )
 
 // Client is used to access
 // the feature flags.
 type Client struct {
-	driver driver.Driver
+	driver       driver.Driver
+	instrumenter Instrumenter
 }
 
 // NewClient initializes a client with a store driver.
 // It assumes that the driver is properly configured.
 // See flipper.NewClient as a shortcut to initialize
 // a client.
-func NewClient(a driver.Driver) *Client {
-	return &Client{a}
+func NewClient(a driver.Driver, opts ...Option) *Client {
+	c := &Client{
+		driver:       a,
+		instrumenter: nopInstrumenter{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // IsEnabled checks if a feature is enabled.
@@ -43,25 +62,123 @@ func NewClient(a driver.Driver) *Client {
 // This check is accumulative, it only returns true if the feature is enabled
 // for every actor. It returns false if the feature is disabled for any of the actors.
 func (c *Client) IsEnabled(featureName string, actors ...actor.Actor) (bool, error) {
+	return c.IsEnabledContext(context.Background(), featureName, actors...)
+}
+
+// IsEnabledContext checks if a feature is enabled like IsEnabled does.
+// It stops waiting for the driver when the context is done.
+func (c *Client) IsEnabledContext(ctx context.Context, featureName string, actors ...actor.Actor) (enabled bool, err error) {
+	defer func(start time.Time) {
+		c.instrument(ctx, start, Event{
+			Operation:   OperationIsEnabled,
+			FeatureName: featureName,
+			Actors:      actorIDs(actors),
+			Result:      enabled,
+			Err:         err,
+		})
+	}(time.Now())
+
+	if len(actors) > 0 {
+		return c.isEnabledForActors(ctx, featureName, actors...)
+	}
+
+	return c.isEnabledGlobally(ctx, featureName)
+}
+
+// IsEnabledMulti checks if many features are enabled like IsEnabled does,
+// and returns the results indexed by feature name.
+// Drivers that implement the driver.MultiGetter interface
+// read every feature in a single round-trip.
+func (c *Client) IsEnabledMulti(featureNames []string, actors ...actor.Actor) (map[string]bool, error) {
+	return c.IsEnabledMultiContext(context.Background(), featureNames, actors...)
+}
+
+// IsEnabledMultiContext checks if many features are enabled like IsEnabledMulti does.
+// It stops waiting for the driver when the context is done.
+func (c *Client) IsEnabledMultiContext(ctx context.Context, featureNames []string, actors ...actor.Actor) (enabled map[string]bool, err error) {
+	defer func(start time.Time) {
+		c.instrument(ctx, start, Event{
+			Operation:    OperationIsEnabledMulti,
+			FeatureNames: featureNames,
+			Actors:       actorIDs(actors),
+			Err:          err,
+		})
+	}(time.Now())
+
+	keys := globalChecks
 	if len(actors) > 0 {
-		return c.isEnabledForActors(featureName, actors...)
+		keys = actorChecks
 	}
 
-	return c.isEnabledGlobally(featureName)
+	features := make([]feature.Feature, 0, len(featureNames))
+	for _, name := range featureNames {
+		features = append(features, feature.NewFeature(name))
+	}
+
+	checks, err := c.getMulti(ctx, features, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled = make(map[string]bool, len(features))
+	for _, f := range features {
+		enabled[f.Name] = isOpen(f, checks[f.Name], actors)
+	}
+	return enabled, nil
+}
+
+// EvaluateAll checks every feature known by the driver for an actor,
+// and returns the results indexed by feature name.
+// It uses only global checks when the actor is nil.
+// It returns ErrListingNotSupported if the driver doesn't implement
+// the driver.Lister interface.
+func (c *Client) EvaluateAll(a actor.Actor) (map[string]bool, error) {
+	return c.EvaluateAllContext(context.Background(), a)
+}
+
+// EvaluateAllContext checks every feature for an actor like EvaluateAll does.
+// It stops waiting for the driver when the context is done.
+// Features are not listed when the context is already done.
+func (c *Client) EvaluateAllContext(ctx context.Context, a actor.Actor) (map[string]bool, error) {
+	features, err := c.featuresContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, f.Name)
+	}
+
+	if a == nil {
+		return c.IsEnabledMultiContext(ctx, names)
+	}
+	return c.IsEnabledMultiContext(ctx, names, a)
 }
 
 // Enable enables a feature globally, for every actor.
+// It clears every other gate for the feature.
 func (c *Client) Enable(featureName string) error {
-	gate := gates.NewBoolGate(true)
-	return c.driver.Enable(feature.NewFeature(featureName), gate)
+	return c.EnableContext(context.Background(), featureName)
+}
+
+// EnableContext enables a feature globally, for every actor.
+// It stops waiting for the driver when the context is done.
+func (c *Client) EnableContext(ctx context.Context, featureName string) error {
+	return c.enable(ctx, featureName, gates.NewBoolGate(true), nil)
 }
 
 // Disable disables a feature globally.
-// Actors might still have the feature enabled if other gates
-// are open.
+// It removes every gate for the feature, so no actor
+// has the feature enabled after that.
 func (c *Client) Disable(featureName string) error {
-	gate := gates.NewBoolGate(false)
-	return c.driver.Disable(feature.NewFeature(featureName), gate)
+	return c.DisableContext(context.Background(), featureName)
+}
+
+// DisableContext disables a feature globally.
+// It stops waiting for the driver when the context is done.
+func (c *Client) DisableContext(ctx context.Context, featureName string) error {
+	return c.disable(ctx, featureName, gates.NewBoolGate(false), nil)
 }
 
 // EnableForActors enables a featue for a list of actors.
@@ -74,7 +191,7 @@ func (c *Client) EnableForActors(featureName string, actors ...actor.Actor) erro
 		set[a.FlipperID()] = a.FlipperID()
 	}
 	gate := gates.NewActorGate(set)
-	return c.driver.Enable(feature.NewFeature(featureName), gate)
+	return c.enable(context.Background(), featureName, gate, actors)
 }
 
 // DisableForActors disables a featue for a list of actors.
@@ -87,7 +204,35 @@ func (c *Client) DisableForActors(featureName string, actors ...actor.Actor) err
 		set[a.FlipperID()] = a.FlipperID()
 	}
 	gate := gates.NewActorGate(set)
-	return c.driver.Disable(feature.NewFeature(featureName), gate)
+	return c.disable(context.Background(), featureName, gate, actors)
+}
+
+// ExcludeActors disables a feature for a list of actors,
+// even when other gates enable it for them.
+func (c *Client) ExcludeActors(featureName string, actors ...actor.Actor) error {
+	if len(actors) == 0 {
+		return errors.New("there are no actors to exclude from the feature")
+	}
+	set := gates.Set{}
+	for _, a := range actors {
+		set[a.FlipperID()] = a.FlipperID()
+	}
+	gate := gates.NewExclusionGate(set)
+	return c.enable(context.Background(), featureName, gate, actors)
+}
+
+// RemoveExcludedActors removes a list of actors from the actors excluded from a feature,
+// so the other gates decide whether the feature is enabled for them.
+func (c *Client) RemoveExcludedActors(featureName string, actors ...actor.Actor) error {
+	if len(actors) == 0 {
+		return errors.New("there are no actors to remove from the excluded actors")
+	}
+	set := gates.Set{}
+	for _, a := range actors {
+		set[a.FlipperID()] = a.FlipperID()
+	}
+	gate := gates.NewExclusionGate(set)
+	return c.disable(context.Background(), featureName, gate, actors)
 }
 
 // EnableForGroups enables a featue for a list of groups.
@@ -100,7 +245,7 @@ func (c *Client) EnableForGroups(featureName string, groups ...string) error {
 		set[n] = n
 	}
 	gate := gates.NewGroupGate(set)
-	return c.driver.Enable(feature.NewFeature(featureName), gate)
+	return c.enable(context.Background(), featureName, gate, nil)
 }
 
 // DisableForGroups disables a feature for a list of groups.
@@ -113,80 +258,259 @@ func (c *Client) DisableForGroups(featureName string, groups ...string) error {
 		set[n] = n
 	}
 	gate := gates.NewGroupGate(set)
-	return c.driver.Disable(feature.NewFeature(featureName), gate)
+	return c.disable(context.Background(), featureName, gate, nil)
 }
 
 // EnableForPercentageOfActors enables a feature for a percentage of the actors checked.
 func (c *Client) EnableForPercentageOfActors(featureName string, percentage int) error {
 	gate := gates.NewPercentageOfActorsGate(percentage)
-	return c.driver.Enable(feature.NewFeature(featureName), gate)
+	return c.enable(context.Background(), featureName, gate, nil)
 }
 
 // DisableForPercentageOfActors disables a feature for a percentage of the actors checked.
 func (c *Client) DisableForPercentageOfActors(featureName string) error {
 	gate := gates.NewPercentageOfActorsGate(0)
-	return c.driver.Disable(feature.NewFeature(featureName), gate)
+	return c.disable(context.Background(), featureName, gate, nil)
 }
 
 // EnableForPercentageOfTime enables a feature for a percentage of the checks.
 func (c *Client) EnableForPercentageOfTime(featureName string, percentage int) error {
 	gate := gates.NewPercentageOfTimeGate(percentage)
-	return c.driver.Enable(feature.NewFeature(featureName), gate)
+	return c.enable(context.Background(), featureName, gate, nil)
+}
+
+// EnableForFractionalPercentageOfTime enables a feature for a percentage of the checks
+// that can include decimals, like 0.5.
+func (c *Client) EnableForFractionalPercentageOfTime(featureName string, percentage float64) error {
+	gate := gates.NewFractionalPercentageOfTimeGate(percentage)
+	return c.enable(context.Background(), featureName, gate, nil)
 }
 
 // DisableForPercentageOfTime disables a feature for a percentage of the checks.
 func (c *Client) DisableForPercentageOfTime(featureName string) error {
 	gate := gates.NewPercentageOfTimeGate(0)
-	return c.driver.Disable(feature.NewFeature(featureName), gate)
+	return c.disable(context.Background(), featureName, gate, nil)
 }
 
-func (c *Client) isEnabledGlobally(featureName string) (bool, error) {
-	feat := feature.NewFeature(featureName)
-	checks, err := c.driver.Get(feat, globalChecks)
+// EnableBetween enables a feature globally during a time window,
+// from start until end. A zero start enables it right away,
+// and a zero end keeps it enabled after start.
+// Windows are checked with the clock set by gates.SetClock.
+func (c *Client) EnableBetween(featureName string, start, end time.Time) error {
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return errors.New("the time window must end after it starts")
+	}
+	gate := gates.NewScheduleGate(start, end)
+	return c.enable(context.Background(), featureName, gate, nil)
+}
+
+// DisableSchedule removes the time window when a feature is enabled.
+func (c *Client) DisableSchedule(featureName string) error {
+	gate := gates.NewScheduleGate(time.Time{}, time.Time{})
+	return c.disable(context.Background(), featureName, gate, nil)
+}
+
+// EnableRamp enables a feature for a percentage of the actors checked
+// that grows from one percentage to another during a period of time, after start.
+// The feature is not enabled by the ramp before start.
+// Actors are enabled in the same order as with EnableForPercentageOfActors,
+// so the actors enabled stay enabled while the percentage grows.
+// Ramps are checked with the clock set by gates.SetClock.
+func (c *Client) EnableRamp(featureName string, start time.Time, duration time.Duration, from, to int) error {
+	if from < 0 || to > 100 || from > to {
+		return errors.New("the ramp percentages must grow between 0 and 100")
+	}
+	if duration < 0 {
+		return errors.New("the ramp duration can't be negative")
+	}
+	gate := gates.NewRampGate(start, duration, from, to)
+	return c.enable(context.Background(), featureName, gate, nil)
+}
+
+// DisableRamp removes the ramp that enables a feature for a growing percentage of actors.
+func (c *Client) DisableRamp(featureName string) error {
+	gate := gates.NewRampGate(time.Time{}, 0, 0, 0)
+	return c.disable(context.Background(), featureName, gate, nil)
+}
+
+// Features returns every feature known by the driver, sorted by name.
+// It returns ErrListingNotSupported if the driver cannot list features.
+func (c *Client) Features() ([]feature.Feature, error) {
+	return c.featuresContext(context.Background())
+}
+
+// featuresContext lists the features if the context is not done.
+// The driver.Lister interface doesn't take a context,
+// so the listing itself can't be cancelled.
+func (c *Client) featuresContext(ctx context.Context) ([]feature.Feature, error) {
+	l, ok := c.driver.(driver.Lister)
+	if !ok {
+		return nil, ErrListingNotSupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	features, err := l.Features()
+	c.instrument(ctx, start, Event{Operation: OperationDriverFeatures, Err: err})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	if checks == nil || len(checks) == 0 {
-		return false, nil
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].Name < features[j].Name
+	})
+	return features, nil
+}
+
+// Add makes a feature known by the driver without enabling it.
+func (c *Client) Add(featureName string) error {
+	l, ok := c.driver.(driver.Lister)
+	if !ok {
+		return ErrListingNotSupported
 	}
+	start := time.Now()
+	err := l.Add(feature.NewFeature(featureName))
+	c.instrument(context.Background(), start, Event{Operation: OperationDriverAdd, FeatureName: featureName, Err: err})
+	return err
+}
 
-	var open bool
-	for _, g := range checks {
-		if g.IsOpen(feat, nil) {
-			open = true
-			break
-		}
+// Remove disables a feature for every gate and removes it
+// from the list of known features.
+func (c *Client) Remove(featureName string) error {
+	l, ok := c.driver.(driver.Lister)
+	if !ok {
+		return ErrListingNotSupported
+	}
+	start := time.Now()
+	err := l.Remove(feature.NewFeature(featureName))
+	c.instrument(context.Background(), start, Event{Operation: OperationDriverRemove, FeatureName: featureName, Err: err})
+	return err
+}
+
+// Clear disables a feature for every gate,
+// but keeps it in the list of known features.
+func (c *Client) Clear(featureName string) error {
+	l, ok := c.driver.(driver.Lister)
+	if !ok {
+		return ErrListingNotSupported
 	}
+	start := time.Now()
+	err := l.Clear(feature.NewFeature(featureName))
+	c.instrument(context.Background(), start, Event{Operation: OperationDriverClear, FeatureName: featureName, Err: err})
+	return err
+}
 
-	return open, nil
+func (c *Client) enable(ctx context.Context, featureName string, gate gates.Gate, actors []actor.Actor) error {
+	start := time.Now()
+	feat := feature.NewFeature(featureName)
+	err := driver.EnableContext(ctx, c.driver, feat, gate)
+	c.instrument(ctx, start, Event{Operation: OperationDriverEnable, FeatureName: featureName, GateKey: gate.Key(), Err: err})
+	c.instrument(ctx, start, Event{
+		Operation:   OperationEnable,
+		FeatureName: featureName,
+		GateKey:     gate.Key(),
+		Actors:      actorIDs(actors),
+		Err:         err,
+	})
+	return err
 }
 
-func (c *Client) isEnabledForActors(featureName string, actors ...actor.Actor) (bool, error) {
+func (c *Client) disable(ctx context.Context, featureName string, gate gates.Gate, actors []actor.Actor) error {
+	start := time.Now()
 	feat := feature.NewFeature(featureName)
-	checks, err := c.driver.Get(feat, actorChecks)
+	err := driver.DisableContext(ctx, c.driver, feat, gate)
+	c.instrument(ctx, start, Event{Operation: OperationDriverDisable, FeatureName: featureName, GateKey: gate.Key(), Err: err})
+	c.instrument(ctx, start, Event{
+		Operation:   OperationDisable,
+		FeatureName: featureName,
+		GateKey:     gate.Key(),
+		Actors:      actorIDs(actors),
+		Err:         err,
+	})
+	return err
+}
+
+func (c *Client) get(ctx context.Context, feat feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	start := time.Now()
+	g, err := driver.GetContext(ctx, c.driver, feat, keys)
+	c.instrument(ctx, start, Event{Operation: OperationDriverGet, FeatureName: feat.Name, GateKeys: keys, Err: err})
+	return g, err
+}
+
+func (c *Client) getMulti(ctx context.Context, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	start := time.Now()
+	g, err := driver.GetMultiContext(ctx, c.driver, features, keys)
+
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, f.Name)
+	}
+	c.instrument(ctx, start, Event{Operation: OperationDriverGetMulti, FeatureNames: names, GateKeys: keys, Err: err})
+	return g, err
+}
+
+func (c *Client) isEnabledGlobally(ctx context.Context, featureName string) (bool, error) {
+	feat := feature.NewFeature(featureName)
+	checks, err := c.get(ctx, feat, globalChecks)
 	if err != nil {
 		return false, err
 	}
 
-	if checks == nil || len(checks) == 0 {
-		return false, nil
+	return isOpen(feat, checks, nil), nil
+}
+
+func (c *Client) isEnabledForActors(ctx context.Context, featureName string, actors ...actor.Actor) (bool, error) {
+	feat := feature.NewFeature(featureName)
+	checks, err := c.get(ctx, feat, actorChecks)
+	if err != nil {
+		return false, err
 	}
 
-	for _, a := range actors {
-		open := false
+	return isOpen(feat, checks, actors), nil
+}
 
-		for _, g := range checks {
-			if g.IsOpen(feat, a) {
-				open = true
-				break
-			}
+// isOpen checks if a feature is open for every actor,
+// or globally when there are not actors.
+// Excluded actors are checked first, the feature is closed
+// if any of the actors is excluded.
+func isOpen(feat feature.Feature, checks []gates.Gate, actors []actor.Actor) bool {
+	if len(checks) == 0 {
+		return false
+	}
+
+	if len(actors) == 0 {
+		return openGate(feat, checks, nil) != nil
+	}
+
+	for _, a := range actors {
+		if excludingGate(feat, checks, a) != nil || openGate(feat, checks, a) == nil {
+			return false
 		}
+	}
 
-		if !open {
-			return false, nil
+	return true
+}
+
+// excludingGate returns the first gate that excludes an actor from a feature,
+// or nil if the actor is not excluded.
+func excludingGate(feat feature.Feature, checks []gates.Gate, a actor.Actor) gates.Gate {
+	for _, g := range checks {
+		if e, ok := g.(gates.ExclusionGateType); ok && e.Excludes(feat, a) {
+			return g
 		}
 	}
+	return nil
+}
 
-	return true, nil
+// openGate returns the first gate open for an actor,
+// or nil if every gate is closed.
+func openGate(feat feature.Feature, checks []gates.Gate, a actor.Actor) gates.Gate {
+	for _, g := range checks {
+		if g.IsOpen(feat, a) {
+			return g
+		}
+	}
+	return nil
 }
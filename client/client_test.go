@@ -1,12 +1,18 @@
 package client
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/calavera/go-flipper/actor"
 	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/driver"
 	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
 	"github.com/calavera/go-flipper/gates"
 	"github.com/stretchr/testify/require"
 )
@@ -109,3 +115,555 @@ func TestClient_IsOpen(t *testing.T) {
 		require.False(t, enabled)
 	})
 }
+
+func TestClient_Features(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+
+	features, err := client.Features()
+	require.NoError(t, err)
+	require.Len(t, features, 0)
+
+	require.NoError(t, client.Add("search"))
+	require.NoError(t, client.Enable("checkout"))
+	require.NoError(t, client.EnableForGroups("search", "admins"))
+
+	features, err = client.Features()
+	require.NoError(t, err)
+	require.Equal(t, []feature.Feature{feature.NewFeature("checkout"), feature.NewFeature("search")}, features)
+
+	require.NoError(t, client.Clear("checkout"))
+
+	enabled, err := client.IsEnabled("checkout")
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	features, err = client.Features()
+	require.NoError(t, err)
+	require.Len(t, features, 2)
+
+	require.NoError(t, client.Remove("search"))
+
+	features, err = client.Features()
+	require.NoError(t, err)
+	require.Equal(t, []feature.Feature{feature.NewFeature("checkout")}, features)
+
+	require.NoError(t, client.Add("search"))
+
+	g, err := client.driver.Get(feature.NewFeature("search"), actorChecks)
+	require.NoError(t, err)
+	require.Len(t, g, 0)
+}
+
+func TestClient_Context(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+
+	require.NoError(t, client.EnableContext(context.Background(), "test"))
+
+	enabled, err := client.IsEnabledContext(context.Background(), "test")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.IsEnabledContext(ctx, "test")
+	require.Equal(t, context.Canceled, err)
+
+	err = client.DisableContext(ctx, "test")
+	require.Equal(t, context.Canceled, err)
+
+	enabled, err = client.IsEnabled("test")
+	require.NoError(t, err)
+	require.True(t, enabled)
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Instrument(ctx context.Context, e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.Duration = 0
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) reset() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := r.events
+	r.events = nil
+	return e
+}
+
+// singleDriver hides the optional interfaces of a driver.
+type singleDriver struct {
+	driver.Driver
+}
+
+func TestClient_IsEnabledMulti(t *testing.T) {
+	a := testhelpers.Actor{ID: "User;1"}
+	b := testhelpers.Actor{ID: "User;2"}
+
+	m := memory.NewDriver()
+	for name, d := range map[string]driver.Driver{"multi getter": m, "single getter": singleDriver{m}} {
+		t.Run(name, func(t *testing.T) {
+			client := NewClient(d)
+			require.NoError(t, client.Enable("search"))
+			require.NoError(t, client.EnableForActors("checkout", a))
+
+			enabled, err := client.IsEnabledMulti([]string{"search", "checkout", "signup"})
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{"search": true, "checkout": false, "signup": false}, enabled)
+
+			enabled, err = client.IsEnabledMulti([]string{"search", "checkout", "signup"}, a)
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{"search": true, "checkout": true, "signup": false}, enabled)
+
+			enabled, err = client.IsEnabledMulti([]string{"search", "checkout"}, a, b)
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{"search": true, "checkout": false}, enabled)
+
+			enabled, err = client.IsEnabledMulti(nil)
+			require.NoError(t, err)
+			require.Len(t, enabled, 0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = client.IsEnabledMultiContext(ctx, []string{"search"})
+			require.Equal(t, context.Canceled, err)
+		})
+	}
+}
+
+func TestClient_EvaluateAll(t *testing.T) {
+	m := memory.NewDriver()
+	client := NewClient(m)
+	a := testhelpers.Actor{ID: "User;1"}
+
+	require.NoError(t, client.Enable("search"))
+	require.NoError(t, client.EnableForActors("checkout", a))
+	require.NoError(t, client.Add("signup"))
+
+	enabled, err := client.EvaluateAll(a)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"search": true, "checkout": true, "signup": false}, enabled)
+
+	enabled, err = client.EvaluateAll(nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"search": true, "checkout": false, "signup": false}, enabled)
+
+	_, err = NewClient(singleDriver{m}).EvaluateAll(a)
+	require.Equal(t, ErrListingNotSupported, err)
+
+	r := &recorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewClient(m, WithInstrumenter(r)).EvaluateAllContext(ctx, a)
+	require.Equal(t, context.Canceled, err)
+	require.Empty(t, r.reset())
+}
+
+func TestClient_Instrumenter(t *testing.T) {
+	r := &recorder{}
+	client := NewClient(memory.NewDriver(), WithInstrumenter(r))
+	a := testhelpers.Actor{ID: "User;1"}
+
+	t.Run("feature checks", func(t *testing.T) {
+		require.NoError(t, client.Enable("test"))
+		require.Equal(t, []Event{
+			{Operation: OperationDriverEnable, FeatureName: "test", GateKey: gates.BoolGateKey},
+			{Operation: OperationEnable, FeatureName: "test", GateKey: gates.BoolGateKey},
+		}, r.reset())
+
+		enabled, err := client.IsEnabled("test", a)
+		require.NoError(t, err)
+		require.True(t, enabled)
+		require.Equal(t, []Event{
+			{Operation: OperationDriverGet, FeatureName: "test", GateKeys: actorChecks},
+			{Operation: OperationIsEnabled, FeatureName: "test", Actors: []string{"User;1"}, Result: true},
+		}, r.reset())
+
+		require.NoError(t, client.Disable("test"))
+		require.Equal(t, []Event{
+			{Operation: OperationDriverDisable, FeatureName: "test", GateKey: gates.BoolGateKey},
+			{Operation: OperationDisable, FeatureName: "test", GateKey: gates.BoolGateKey},
+		}, r.reset())
+	})
+
+	t.Run("multiple features", func(t *testing.T) {
+		enabled, err := client.IsEnabledMulti([]string{"test", "other"}, a)
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"test": false, "other": false}, enabled)
+		require.Equal(t, []Event{
+			{Operation: OperationDriverGetMulti, FeatureNames: []string{"test", "other"}, GateKeys: actorChecks},
+			{Operation: OperationIsEnabledMulti, FeatureNames: []string{"test", "other"}, Actors: []string{"User;1"}},
+		}, r.reset())
+	})
+
+	t.Run("other gates", func(t *testing.T) {
+		require.NoError(t, client.EnableForActors("test", a))
+		require.NoError(t, client.DisableForPercentageOfTime("test"))
+		require.Equal(t, []Event{
+			{Operation: OperationDriverEnable, FeatureName: "test", GateKey: gates.ActorGateKey},
+			{Operation: OperationEnable, FeatureName: "test", GateKey: gates.ActorGateKey, Actors: []string{"User;1"}},
+			{Operation: OperationDriverDisable, FeatureName: "test", GateKey: gates.PercentageOfTimeGateKey},
+			{Operation: OperationDisable, FeatureName: "test", GateKey: gates.PercentageOfTimeGateKey},
+		}, r.reset())
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		require.NoError(t, client.Add("search"))
+		_, err := client.Features()
+		require.NoError(t, err)
+		require.Equal(t, []Event{
+			{Operation: OperationDriverAdd, FeatureName: "search"},
+			{Operation: OperationDriverFeatures},
+		}, r.reset())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.IsEnabledContext(ctx, "test")
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, []Event{
+			{Operation: OperationDriverGet, FeatureName: "test", GateKeys: globalChecks, Err: context.Canceled},
+			{Operation: OperationIsEnabled, FeatureName: "test", Err: context.Canceled},
+		}, r.reset())
+	})
+
+	t.Run("durations", func(t *testing.T) {
+		var d time.Duration
+		client := NewClient(memory.NewDriver(), WithInstrumenter(InstrumenterFunc(func(ctx context.Context, e Event) {
+			d = e.Duration
+		})))
+
+		_, err := client.IsEnabled("test")
+		require.NoError(t, err)
+		require.True(t, d > 0)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		client := NewClient(memory.NewDriver(), WithInstrumenter(nil))
+		require.NoError(t, client.Enable("test"))
+	})
+}
+
+func TestClient_Explain(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+	alice := testhelpers.Actor{ID: "User;alice"}
+	bob := testhelpers.Actor{ID: "User;bob"}
+
+	gates.RegisterGroup("explain_staff", func(a actor.Actor) bool {
+		return a.FlipperID() == bob.FlipperID()
+	})
+
+	t.Run("unknown feature", func(t *testing.T) {
+		e, err := client.Explain("checkout", alice)
+		require.NoError(t, err)
+		require.Equal(t, Explanation{
+			FeatureName: "checkout",
+			Checks:      []ActorExplanation{{ActorID: "User;alice"}},
+		}, e)
+	})
+
+	t.Run("actors", func(t *testing.T) {
+		require.NoError(t, client.EnableForActors("checkout", alice))
+		require.NoError(t, client.EnableForGroups("checkout", "explain_staff"))
+
+		e, err := client.Explain("checkout", alice, bob)
+		require.NoError(t, err)
+		require.True(t, e.Enabled)
+		require.Len(t, e.Gates, 2)
+		require.Equal(t, []ActorExplanation{
+			{
+				ActorID: "User;alice",
+				Enabled: true,
+				Gates: []GateResult{
+					{Key: gates.ActorGateKey, Open: true},
+					{Key: gates.GroupGateKey, Open: false},
+				},
+				DecidingGate: gates.ActorGateKey,
+			},
+			{
+				ActorID: "User;bob",
+				Enabled: true,
+				Gates: []GateResult{
+					{Key: gates.ActorGateKey, Open: false},
+					{Key: gates.GroupGateKey, Open: true},
+				},
+				DecidingGate: gates.GroupGateKey,
+			},
+		}, e.Checks)
+
+		require.NoError(t, client.DisableForGroups("checkout", "explain_staff"))
+
+		e, err = client.Explain("checkout", bob, alice)
+		require.NoError(t, err)
+		require.False(t, e.Enabled)
+		require.Len(t, e.Checks, 2)
+		require.False(t, e.Checks[0].Enabled)
+		require.Empty(t, e.Checks[0].DecidingGate)
+		require.True(t, e.Checks[1].Enabled)
+
+		enabled, err := client.IsEnabled("checkout", bob, alice)
+		require.NoError(t, err)
+		require.Equal(t, e.Enabled, enabled)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		require.NoError(t, client.Enable("checkout"))
+
+		e, err := client.Explain("checkout")
+		require.NoError(t, err)
+		require.Equal(t, Explanation{
+			FeatureName: "checkout",
+			Enabled:     true,
+			Gates:       []gates.Gate{gates.NewBoolGate(true)},
+			Checks: []ActorExplanation{{
+				Enabled:      true,
+				Gates:        []GateResult{{Key: gates.BoolGateKey, Open: true}},
+				DecidingGate: gates.BoolGateKey,
+			}},
+		}, e)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.ExplainContext(ctx, "checkout", alice)
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestClient_Feature(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+
+	f, err := client.Feature("checkout")
+	require.NoError(t, err)
+	require.Equal(t, FeatureState{Name: "checkout", State: StateOff}, f)
+
+	require.NoError(t, client.EnableForActors("checkout", testhelpers.Actor{ID: "User;2"}, testhelpers.Actor{ID: "User;1"}))
+	require.NoError(t, client.EnableForGroups("checkout", "staff", "admins"))
+	require.NoError(t, client.EnableForPercentageOfActors("checkout", 25))
+	require.NoError(t, client.EnableForFractionalPercentageOfTime("checkout", 0.5))
+
+	f, err = client.Feature("checkout")
+	require.NoError(t, err)
+	require.Equal(t, FeatureState{
+		Name:  "checkout",
+		State: StateConditional,
+		GateValues: GateValues{
+			Actors:             []string{"User;1", "User;2"},
+			Groups:             []string{"admins", "staff"},
+			PercentageOfActors: 25,
+			PercentageOfTime:   0.5,
+		},
+	}, f)
+
+	require.NoError(t, client.Enable("checkout"))
+
+	f, err = client.Feature("checkout")
+	require.NoError(t, err)
+	require.Equal(t, StateOn, f.State)
+	require.True(t, f.Boolean)
+
+	require.NoError(t, client.Disable("checkout"))
+	require.NoError(t, client.EnableForPercentageOfActors("checkout", 100))
+
+	f, err = client.Feature("checkout")
+	require.NoError(t, err)
+	require.Equal(t, StateOn, f.State)
+	require.False(t, f.Boolean)
+
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, client.EnableBetween("launch", start, time.Time{}))
+
+	f, err = client.Feature("launch")
+	require.NoError(t, err)
+	require.Equal(t, FeatureState{
+		Name:       "launch",
+		State:      StateConditional,
+		GateValues: GateValues{Schedule: &Schedule{Start: start}},
+	}, f)
+
+	require.NoError(t, client.EnableRamp("rollout", start, 48*time.Hour, 1, 100))
+
+	f, err = client.Feature("rollout")
+	require.NoError(t, err)
+	require.Equal(t, FeatureState{
+		Name:       "rollout",
+		State:      StateConditional,
+		GateValues: GateValues{Ramp: &Ramp{Start: start, Duration: 48 * time.Hour, From: 1, To: 100}},
+	}, f)
+
+	require.NoError(t, client.Enable("beta"))
+	require.NoError(t, client.ExcludeActors("beta", testhelpers.Actor{ID: "User;2"}, testhelpers.Actor{ID: "User;1"}))
+
+	f, err = client.Feature("beta")
+	require.NoError(t, err)
+	require.Equal(t, FeatureState{
+		Name:       "beta",
+		State:      StateConditional,
+		GateValues: GateValues{Boolean: true, ExcludedActors: []string{"User;1", "User;2"}},
+	}, f)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.FeatureContext(ctx, "checkout")
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestClient_Schedule(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+	a := testhelpers.Actor{ID: "User;1"}
+
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	now := start.Add(-time.Minute)
+	gates.SetClock(gates.ClockFunc(func() time.Time { return now }))
+	defer gates.SetClock(nil)
+
+	require.NoError(t, client.EnableBetween("promotion", start, end))
+
+	check := func(expected bool) {
+		enabled, err := client.IsEnabled("promotion")
+		require.NoError(t, err)
+		require.Equal(t, expected, enabled, now)
+
+		enabled, err = client.IsEnabled("promotion", a)
+		require.NoError(t, err)
+		require.Equal(t, expected, enabled, now)
+	}
+
+	check(false)
+
+	now = start
+	check(true)
+
+	now = end.Add(-time.Second)
+	check(true)
+
+	now = end
+	check(false)
+
+	now = start
+	require.NoError(t, client.DisableSchedule("promotion"))
+	check(false)
+
+	require.Error(t, client.EnableBetween("promotion", end, start))
+	require.Error(t, client.EnableBetween("promotion", start, start))
+}
+
+func TestClient_Ramp(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(-time.Minute)
+	gates.SetClock(gates.ClockFunc(func() time.Time { return now }))
+	defer gates.SetClock(nil)
+
+	require.NoError(t, client.EnableRamp("rollout", start, 10*time.Hour, 20, 100))
+
+	enabled := func() map[string]bool {
+		e := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			a := testhelpers.Actor{ID: fmt.Sprintf("User;%d", i)}
+			ok, err := client.IsEnabled("rollout", a)
+			require.NoError(t, err)
+			if ok {
+				e[a.ID] = true
+			}
+		}
+		return e
+	}
+
+	require.Empty(t, enabled())
+
+	now = start.Add(3 * time.Hour)
+	early := enabled()
+	require.NotEmpty(t, early)
+
+	now = start.Add(7 * time.Hour)
+	later := enabled()
+	require.True(t, len(later) > len(early))
+	for id := range early {
+		require.True(t, later[id], id)
+	}
+
+	now = start.Add(10 * time.Hour)
+	require.Len(t, enabled(), 100)
+
+	ok, err := client.IsEnabled("rollout")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, client.DisableRamp("rollout"))
+	require.Empty(t, enabled())
+
+	require.Error(t, client.EnableRamp("rollout", start, time.Hour, 50, 10))
+	require.Error(t, client.EnableRamp("rollout", start, time.Hour, -1, 10))
+	require.Error(t, client.EnableRamp("rollout", start, time.Hour, 0, 101))
+	require.Error(t, client.EnableRamp("rollout", start, -time.Hour, 0, 100))
+}
+
+func TestClient_ExcludeActors(t *testing.T) {
+	client := NewClient(memory.NewDriver())
+	excluded := testhelpers.Actor{ID: "User;1"}
+	other := testhelpers.Actor{ID: "User;2"}
+
+	check := func(a actor.Actor, expected bool) {
+		enabled, err := client.IsEnabled("checkout", a)
+		require.NoError(t, err)
+		require.Equal(t, expected, enabled, a.FlipperID())
+	}
+
+	require.NoError(t, client.ExcludeActors("checkout", excluded))
+	check(excluded, false)
+	check(other, false)
+
+	// enabling the boolean gate clears the exclusions too.
+	require.NoError(t, client.Enable("checkout"))
+	check(excluded, true)
+
+	require.NoError(t, client.EnableForActors("checkout", excluded))
+	require.NoError(t, client.EnableForPercentageOfActors("checkout", 100))
+	require.NoError(t, client.ExcludeActors("checkout", excluded))
+	check(excluded, false)
+	check(other, true)
+
+	enabled, err := client.IsEnabled("checkout", other, excluded)
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	enabled, err = client.IsEnabled("checkout")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	multi, err := client.IsEnabledMulti([]string{"checkout"}, excluded)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"checkout": false}, multi)
+
+	e, err := client.Explain("checkout", excluded, other)
+	require.NoError(t, err)
+	require.False(t, e.Enabled)
+	require.Len(t, e.Checks, 2)
+	require.False(t, e.Checks[0].Enabled)
+	require.Equal(t, gates.ExclusionGateKey, e.Checks[0].DecidingGate)
+	require.True(t, e.Checks[1].Enabled)
+	require.Equal(t, gates.BoolGateKey, e.Checks[1].DecidingGate)
+
+	require.NoError(t, client.RemoveExcludedActors("checkout", excluded))
+	check(excluded, true)
+
+	require.Error(t, client.ExcludeActors("checkout"))
+	require.Error(t, client.RemoveExcludedActors("checkout"))
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+)
+
+// Explanation describes how a feature check was resolved.
+type Explanation struct {
+	FeatureName string
+	// Enabled is the result of the check,
+	// the same value IsEnabled returns for the same gates.
+	Enabled bool
+	// Gates are the gates fetched from the driver.
+	Gates []gates.Gate
+	// Checks has the result for each actor, in the same order they were given.
+	// Global checks, without actors, have a single result with an empty ActorID.
+	Checks []ActorExplanation
+}
+
+// ActorExplanation describes how a feature check was resolved for an actor.
+type ActorExplanation struct {
+	ActorID string
+	Enabled bool
+	// Gates has the result of every gate fetched from the driver.
+	Gates []GateResult
+	// DecidingGate is the first gate open for the actor, the one that enabled the feature,
+	// or the gate that excluded the actor, the one that disabled it.
+	// It's empty when every gate is closed.
+	DecidingGate gates.GateKey
+}
+
+// GateResult is the result of checking a gate for an actor.
+type GateResult struct {
+	Key  gates.GateKey
+	Open bool
+}
+
+// Explain checks if a feature is enabled like IsEnabled does,
+// and returns the result of every gate for every actor.
+// Unlike IsEnabled, it checks every actor even if the feature
+// is disabled for one of them.
+// Percentage of time gates are random, their results in an explanation
+// don't predict the results of other checks.
+func (c *Client) Explain(featureName string, actors ...actor.Actor) (Explanation, error) {
+	return c.ExplainContext(context.Background(), featureName, actors...)
+}
+
+// ExplainContext explains a feature check like Explain does.
+// It stops waiting for the driver when the context is done.
+func (c *Client) ExplainContext(ctx context.Context, featureName string, actors ...actor.Actor) (Explanation, error) {
+	keys := globalChecks
+	if len(actors) > 0 {
+		keys = actorChecks
+	}
+
+	feat := feature.NewFeature(featureName)
+	checks, err := c.get(ctx, feat, keys)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	e := Explanation{
+		FeatureName: featureName,
+		Gates:       checks,
+	}
+
+	if len(actors) == 0 {
+		e.Checks = []ActorExplanation{explainActor(feat, checks, nil)}
+	} else {
+		for _, a := range actors {
+			e.Checks = append(e.Checks, explainActor(feat, checks, a))
+		}
+	}
+
+	e.Enabled = true
+	for _, ae := range e.Checks {
+		if !ae.Enabled {
+			e.Enabled = false
+			break
+		}
+	}
+
+	return e, nil
+}
+
+func explainActor(feat feature.Feature, checks []gates.Gate, a actor.Actor) ActorExplanation {
+	var ae ActorExplanation
+	if a != nil {
+		ae.ActorID = a.FlipperID()
+	}
+
+	// excluded actors are checked first, like IsEnabled does.
+	var excluding gates.Gate
+	if a != nil {
+		excluding = excludingGate(feat, checks, a)
+	}
+
+	for _, g := range checks {
+		open := g.IsOpen(feat, a)
+		if open && !ae.Enabled && excluding == nil {
+			ae.Enabled = true
+			ae.DecidingGate = g.Key()
+		}
+		ae.Gates = append(ae.Gates, GateResult{Key: g.Key(), Open: open})
+	}
+
+	if excluding != nil {
+		ae.DecidingGate = excluding.Key()
+	}
+
+	return ae
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/gates"
+)
+
+// Operation identifies the operation described by an Event.
+type Operation string
+
+const (
+	// OperationIsEnabled is a feature check, like Client.IsEnabled.
+	OperationIsEnabled Operation = "is_enabled"
+	// OperationIsEnabledMulti is a check of many features, like Client.IsEnabledMulti.
+	OperationIsEnabledMulti Operation = "is_enabled_multi"
+	// OperationEnable opens a gate for a feature, like Client.Enable or Client.EnableForActors.
+	OperationEnable Operation = "enable"
+	// OperationDisable closes a gate for a feature, like Client.Disable or Client.DisableForActors.
+	OperationDisable Operation = "disable"
+
+	// OperationDriverGet is a call to the driver's Get method.
+	OperationDriverGet Operation = "driver_get"
+	// OperationDriverGetMulti reads many features from the driver, see driver.GetMultiContext.
+	OperationDriverGetMulti Operation = "driver_get_multi"
+	// OperationDriverEnable is a call to the driver's Enable method.
+	OperationDriverEnable Operation = "driver_enable"
+	// OperationDriverDisable is a call to the driver's Disable method.
+	OperationDriverDisable Operation = "driver_disable"
+	// OperationDriverFeatures is a call to the driver's Features method.
+	OperationDriverFeatures Operation = "driver_features"
+	// OperationDriverAdd is a call to the driver's Add method.
+	OperationDriverAdd Operation = "driver_add"
+	// OperationDriverRemove is a call to the driver's Remove method.
+	OperationDriverRemove Operation = "driver_remove"
+	// OperationDriverClear is a call to the driver's Clear method.
+	OperationDriverClear Operation = "driver_clear"
+)
+
+// Event describes an operation performed by a client.
+// Fields that don't apply to an operation have their zero value.
+type Event struct {
+	Operation Operation
+	// FeatureName is empty when the driver lists features.
+	FeatureName string
+	// FeatureNames are the features checked or read at once.
+	FeatureNames []string
+	// GateKey is the gate opened or closed.
+	GateKey gates.GateKey
+	// GateKeys are the gates requested from the driver.
+	GateKeys []gates.GateKey
+	// Actors are the flipper ids of the actors checked, enabled or disabled.
+	Actors []string
+	// Result is the result of a feature check.
+	Result   bool
+	Duration time.Duration
+	Err      error
+}
+
+// Instrumenter receives an Event after every operation performed by a client.
+// Instrumenters are called synchronously, they should return quickly.
+// Implementations must be safe for concurrent use.
+type Instrumenter interface {
+	Instrument(ctx context.Context, e Event)
+}
+
+// InstrumenterFunc is an adapter to use ordinary functions as Instrumenter.
+type InstrumenterFunc func(ctx context.Context, e Event)
+
+// Instrument calls f(ctx, e).
+func (f InstrumenterFunc) Instrument(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+// nopInstrumenter is the default Instrumenter, it ignores every event.
+type nopInstrumenter struct{}
+
+func (nopInstrumenter) Instrument(context.Context, Event) {}
+
+// Option configures optional features of a client.
+type Option func(*Client)
+
+// WithInstrumenter sets the Instrumenter that receives the client's events.
+// Passing nil disables instrumentation.
+func WithInstrumenter(i Instrumenter) Option {
+	return func(c *Client) {
+		if i == nil {
+			i = nopInstrumenter{}
+		}
+		c.instrumenter = i
+	}
+}
+
+func (c *Client) instrument(ctx context.Context, start time.Time, e Event) {
+	e.Duration = time.Since(start)
+	c.instrumenter.Instrument(ctx, e)
+}
+
+func actorIDs(actors []actor.Actor) []string {
+	if len(actors) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(actors))
+	for _, a := range actors {
+		ids = append(ids, a.FlipperID())
+	}
+	return ids
+}
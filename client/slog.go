@@ -0,0 +1,68 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogInstrumenter is an Instrumenter that logs every event with a slog.Logger.
+// Events with errors are always logged with the error level.
+type SlogInstrumenter struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlogInstrumenter initializes an instrumenter that logs events with a given level.
+// It uses slog.Default when logger is nil.
+func NewSlogInstrumenter(logger *slog.Logger, level slog.Level) *SlogInstrumenter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogInstrumenter{logger: logger, level: level}
+}
+
+// Instrument logs an event.
+func (s *SlogInstrumenter) Instrument(ctx context.Context, e Event) {
+	level := s.level
+	if e.Err != nil {
+		level = slog.LevelError
+	}
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", string(e.Operation)),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.FeatureName != "" {
+		attrs = append(attrs, slog.String("feature", e.FeatureName))
+	}
+	if len(e.FeatureNames) > 0 {
+		attrs = append(attrs, slog.Any("features", e.FeatureNames))
+	}
+	if e.GateKey != "" {
+		attrs = append(attrs, slog.String("gate", string(e.GateKey)))
+	}
+	if len(e.GateKeys) > 0 {
+		keys := make([]string, 0, len(e.GateKeys))
+		for _, k := range e.GateKeys {
+			keys = append(keys, string(k))
+		}
+		attrs = append(attrs, slog.Any("gates", keys))
+	}
+	if len(e.Actors) > 0 {
+		attrs = append(attrs, slog.Any("actors", e.Actors))
+	}
+	if e.Operation == OperationIsEnabled {
+		attrs = append(attrs, slog.Bool("result", e.Result))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+
+	s.logger.LogAttrs(ctx, level, "flipper "+string(e.Operation), attrs...)
+}
@@ -0,0 +1,78 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogInstrumenter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	decode := func() []map[string]interface{} {
+		var entries []map[string]interface{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var e map[string]interface{}
+			require.NoError(t, dec.Decode(&e))
+			delete(e, "time")
+			delete(e, "duration")
+			entries = append(entries, e)
+		}
+		return entries
+	}
+
+	t.Run("logs events", func(t *testing.T) {
+		client := NewClient(memory.NewDriver(), WithInstrumenter(NewSlogInstrumenter(logger, slog.LevelInfo)))
+
+		_, err := client.IsEnabled("search", testhelpers.Actor{ID: "User;1"})
+		require.NoError(t, err)
+
+		require.Equal(t, []map[string]interface{}{
+			{
+				"level":     "INFO",
+				"msg":       "flipper driver_get",
+				"operation": "driver_get",
+				"feature":   "search",
+				"gates":     []interface{}{"excluded_actors", "boolean", "actors", "groups", "percentage_of_actors", "percentage_of_time", "schedule", "ramp"},
+			},
+			{
+				"level":     "INFO",
+				"msg":       "flipper is_enabled",
+				"operation": "is_enabled",
+				"feature":   "search",
+				"actors":    []interface{}{"User;1"},
+				"result":    false,
+			},
+		}, decode())
+	})
+
+	t.Run("errors are always logged", func(t *testing.T) {
+		client := NewClient(memory.NewDriver(), WithInstrumenter(NewSlogInstrumenter(logger, slog.LevelDebug)))
+
+		require.NoError(t, client.Enable("search"))
+		require.Len(t, decode(), 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Error(t, client.EnableContext(ctx, "search"))
+
+		entries := decode()
+		require.Len(t, entries, 2)
+		for _, e := range entries {
+			require.Equal(t, "ERROR", e["level"])
+			require.Equal(t, "context canceled", e["error"])
+			require.Equal(t, "boolean", e["gate"])
+		}
+	})
+}
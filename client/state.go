@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+)
+
+// State is the state of a feature, like the Ruby gem's Feature#state.
+type State string
+
+const (
+	// StateOn means that the feature is enabled for everyone.
+	StateOn State = "on"
+	// StateOff means that the feature is disabled for everyone.
+	StateOff State = "off"
+	// StateConditional means that the feature is enabled for some actors or checks.
+	StateConditional State = "conditional"
+)
+
+// GateValues holds the value of every gate for a feature,
+// like the Ruby gem's Feature#gate_values.
+type GateValues struct {
+	Boolean bool
+	// Actors are the flipper ids of the actors enabled, sorted.
+	Actors []string
+	// Groups are the names of the groups enabled, sorted.
+	Groups             []string
+	PercentageOfActors float64
+	PercentageOfTime   float64
+	// Schedule is the time window when the feature is enabled, nil if there's none.
+	Schedule *Schedule
+	// Ramp is the percentage of actors that grows over time, nil if there's none.
+	Ramp *Ramp
+	// ExcludedActors are the flipper ids of the actors excluded, sorted.
+	ExcludedActors []string
+}
+
+// Schedule is a time window, zero times mean that it's open on that side.
+type Schedule struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Ramp is a percentage of actors that grows from one value to another
+// during a period of time, after the start time.
+type Ramp struct {
+	Start    time.Time
+	Duration time.Duration
+	From     int
+	To       int
+}
+
+// FeatureState is the configuration of a feature.
+type FeatureState struct {
+	Name  string
+	State State
+	GateValues
+}
+
+// Feature returns the state and the gate values of a feature.
+// Unknown features are off.
+func (c *Client) Feature(featureName string) (FeatureState, error) {
+	return c.FeatureContext(context.Background(), featureName)
+}
+
+// FeatureContext returns the state and the gate values of a feature.
+// It stops waiting for the driver when the context is done.
+func (c *Client) FeatureContext(ctx context.Context, featureName string) (FeatureState, error) {
+	checks, err := c.get(ctx, feature.NewFeature(featureName), actorChecks)
+	if err != nil {
+		return FeatureState{}, err
+	}
+
+	s := FeatureState{Name: featureName}
+	for _, g := range checks {
+		switch g.Key() {
+		case gates.BoolGateKey:
+			if b, ok := g.(gates.BoolGateType); ok {
+				s.Boolean = b.BoolValue()
+			}
+		case gates.ActorGateKey:
+			if set, ok := g.(gates.SetGateType); ok {
+				s.Actors = sortedValues(set.SetValue())
+			}
+		case gates.GroupGateKey:
+			if set, ok := g.(gates.SetGateType); ok {
+				s.Groups = sortedValues(set.SetValue())
+			}
+		case gates.ExclusionGateKey:
+			if set, ok := g.(gates.SetGateType); ok {
+				s.ExcludedActors = sortedValues(set.SetValue())
+			}
+		case gates.PercentageOfActorsGateKey:
+			if i, ok := g.(gates.IntGateType); ok {
+				s.PercentageOfActors = gates.NumberValue(i)
+			}
+		case gates.PercentageOfTimeGateKey:
+			if i, ok := g.(gates.IntGateType); ok {
+				s.PercentageOfTime = gates.NumberValue(i)
+			}
+		case gates.ScheduleGateKey:
+			if tr, ok := g.(gates.TimeRangeGateType); ok {
+				start, end := tr.TimeRange()
+				s.Schedule = &Schedule{Start: start, End: end}
+			}
+		case gates.RampGateKey:
+			if r, ok := g.(gates.RampGateType); ok {
+				start, duration, from, to := r.Ramp()
+				s.Ramp = &Ramp{Start: start, Duration: duration, From: from, To: to}
+			}
+		}
+	}
+
+	s.State = s.state()
+	return s, nil
+}
+
+// state follows the same rules as the Ruby gem:
+// a feature is on when the boolean gate is enabled or any percentage is 100,
+// and it's conditional when any other gate is enabled, including a schedule or a ramp.
+// Features that would be on are conditional when they exclude actors,
+// and excluding actors doesn't make a feature conditional by itself.
+func (v GateValues) state() State {
+	switch {
+	case v.Boolean || v.PercentageOfActors >= 100 || v.PercentageOfTime >= 100:
+		if len(v.ExcludedActors) > 0 {
+			return StateConditional
+		}
+		return StateOn
+	case len(v.Actors) > 0 || len(v.Groups) > 0 || v.PercentageOfActors > 0 || v.PercentageOfTime > 0 || v.Schedule != nil || v.Ramp != nil:
+		return StateConditional
+	default:
+		return StateOff
+	}
+}
+
+func sortedValues(s gates.Set) []string {
+	v := make([]string, 0, len(s))
+	for k := range s {
+		v = append(v, k)
+	}
+	sort.Strings(v)
+	return v
+}
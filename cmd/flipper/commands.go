@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/export"
+	"github.com/pkg/errors"
+)
+
+// cli holds everything commands need to run.
+type cli struct {
+	env
+	driver driver.Driver
+	client *client.Client
+	json   bool
+}
+
+type command struct {
+	usage string
+	// minArgs and maxArgs limit the number of arguments, maxArgs is unlimited when it's -1.
+	minArgs int
+	maxArgs int
+	run     func(c *cli, args []string) error
+}
+
+func (cmd command) validate(args []string) error {
+	if len(args) < cmd.minArgs || (cmd.maxArgs >= 0 && len(args) > cmd.maxArgs) {
+		return errors.New("wrong number of arguments")
+	}
+	return nil
+}
+
+var commands = map[string]command{
+	"list": {
+		usage: "",
+		run:   list,
+	},
+	"show": {
+		usage:   "<feature>",
+		minArgs: 1, maxArgs: 1,
+		run: func(c *cli, args []string) error {
+			return c.show(args[0])
+		},
+	},
+	"enable": {
+		usage:   "<feature>",
+		minArgs: 1, maxArgs: 1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.Enable(args[0]))
+		},
+	},
+	"disable": {
+		usage:   "<feature>",
+		minArgs: 1, maxArgs: 1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.Disable(args[0]))
+		},
+	},
+	"enable-actor": {
+		usage:   "<feature> <flipper id>...",
+		minArgs: 2, maxArgs: -1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.EnableForActors(args[0], actors(args[1:])...))
+		},
+	},
+	"disable-actor": {
+		usage:   "<feature> <flipper id>...",
+		minArgs: 2, maxArgs: -1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.DisableForActors(args[0], actors(args[1:])...))
+		},
+	},
+	"enable-group": {
+		usage:   "<feature> <group>...",
+		minArgs: 2, maxArgs: -1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.EnableForGroups(args[0], args[1:]...))
+		},
+	},
+	"disable-group": {
+		usage:   "<feature> <group>...",
+		minArgs: 2, maxArgs: -1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.DisableForGroups(args[0], args[1:]...))
+		},
+	},
+	"enable-percentage-of-actors": {
+		usage:   "<feature> <percentage>",
+		minArgs: 2, maxArgs: 2,
+		run: func(c *cli, args []string) error {
+			p, err := strconv.Atoi(args[1])
+			if err != nil || p < 0 || p > 100 {
+				return errors.Errorf("invalid percentage %q, use an integer between 0 and 100", args[1])
+			}
+			return c.update(args[0], c.client.EnableForPercentageOfActors(args[0], p))
+		},
+	},
+	"disable-percentage-of-actors": {
+		usage:   "<feature>",
+		minArgs: 1, maxArgs: 1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.DisableForPercentageOfActors(args[0]))
+		},
+	},
+	"enable-percentage-of-time": {
+		usage:   "<feature> <percentage>",
+		minArgs: 2, maxArgs: 2,
+		run: func(c *cli, args []string) error {
+			p, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || p < 0 || p > 100 {
+				return errors.Errorf("invalid percentage %q, use a number between 0 and 100", args[1])
+			}
+			return c.update(args[0], c.client.EnableForFractionalPercentageOfTime(args[0], p))
+		},
+	},
+	"disable-percentage-of-time": {
+		usage:   "<feature>",
+		minArgs: 1, maxArgs: 1,
+		run: func(c *cli, args []string) error {
+			return c.update(args[0], c.client.DisableForPercentageOfTime(args[0]))
+		},
+	},
+	"export": {
+		usage:   "[file]",
+		minArgs: 0, maxArgs: 1,
+		run:     exportFeatures,
+	},
+	"import": {
+		usage:   "[-replace] [file]",
+		minArgs: 0, maxArgs: 2,
+		run:     importFeatures,
+	},
+}
+
+// featureOutput is the JSON representation of a feature.
+// Gates that the Ruby gem doesn't have are only included when they are set.
+type featureOutput struct {
+	Name               string          `json:"name"`
+	State              string          `json:"state"`
+	Boolean            bool            `json:"boolean"`
+	Actors             []string        `json:"actors"`
+	Groups             []string        `json:"groups"`
+	PercentageOfActors float64         `json:"percentage_of_actors"`
+	PercentageOfTime   float64         `json:"percentage_of_time"`
+	Schedule           *scheduleOutput `json:"schedule,omitempty"`
+	Ramp               *rampOutput     `json:"ramp,omitempty"`
+	ExcludedActors     []string        `json:"excluded_actors,omitempty"`
+}
+
+// scheduleOutput is the JSON representation of a time window,
+// with times in RFC 3339 format. Empty times are open ended.
+type scheduleOutput struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+func (s scheduleOutput) String() string {
+	switch {
+	case s.End == "":
+		return "from " + s.Start
+	case s.Start == "":
+		return "until " + s.End
+	default:
+		return "from " + s.Start + " until " + s.End
+	}
+}
+
+// rampOutput is the JSON representation of a ramp, with the start time
+// in RFC 3339 format and the duration in the format of time.ParseDuration.
+type rampOutput struct {
+	Start    string `json:"start,omitempty"`
+	Duration string `json:"duration"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+}
+
+func (r rampOutput) String() string {
+	s := fmt.Sprintf("%d%% to %d%% over %s", r.From, r.To, r.Duration)
+	if r.Start != "" {
+		s += " from " + r.Start
+	}
+	return s
+}
+
+func newFeatureOutput(f client.FeatureState) featureOutput {
+	o := featureOutput{
+		Name:               f.Name,
+		State:              string(f.State),
+		Boolean:            f.Boolean,
+		Actors:             f.Actors,
+		Groups:             f.Groups,
+		PercentageOfActors: f.PercentageOfActors,
+		PercentageOfTime:   f.PercentageOfTime,
+		ExcludedActors:     f.ExcludedActors,
+	}
+	if o.Actors == nil {
+		o.Actors = []string{}
+	}
+	if o.Groups == nil {
+		o.Groups = []string{}
+	}
+	if f.Schedule != nil {
+		o.Schedule = &scheduleOutput{Start: formatTime(f.Schedule.Start), End: formatTime(f.Schedule.End)}
+	}
+	if f.Ramp != nil {
+		o.Ramp = &rampOutput{
+			Start:    formatTime(f.Ramp.Start),
+			Duration: f.Ramp.Duration.String(),
+			From:     f.Ramp.From,
+			To:       f.Ramp.To,
+		}
+	}
+	return o
+}
+
+// formatTime formats times in RFC 3339 format, in UTC.
+// Zero times are empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func list(c *cli, args []string) error {
+	features, err := c.client.Features()
+	if err != nil {
+		return err
+	}
+
+	out := make([]featureOutput, 0, len(features))
+	for _, f := range features {
+		s, err := c.client.Feature(f.Name)
+		if err != nil {
+			return err
+		}
+		out = append(out, newFeatureOutput(s))
+	}
+
+	if c.json {
+		return c.printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	for _, f := range out {
+		fmt.Fprintf(w, "%s\t%s\n", f.Name, f.State)
+	}
+	return w.Flush()
+}
+
+func (c *cli) show(featureName string) error {
+	s, err := c.client.Feature(featureName)
+	if err != nil {
+		return err
+	}
+
+	f := newFeatureOutput(s)
+	if c.json {
+		return c.printJSON(f)
+	}
+
+	values := [][2]string{
+		{"boolean", strconv.FormatBool(f.Boolean)},
+		{"actors", strings.Join(f.Actors, ", ")},
+		{"groups", strings.Join(f.Groups, ", ")},
+		{"percentage of actors", strconv.FormatFloat(f.PercentageOfActors, 'f', -1, 64) + "%"},
+		{"percentage of time", strconv.FormatFloat(f.PercentageOfTime, 'f', -1, 64) + "%"},
+	}
+	if f.Schedule != nil {
+		values = append(values, [2]string{"schedule", f.Schedule.String()})
+	}
+	if f.Ramp != nil {
+		values = append(values, [2]string{"ramp", f.Ramp.String()})
+	}
+	if len(f.ExcludedActors) > 0 {
+		values = append(values, [2]string{"excluded actors", strings.Join(f.ExcludedActors, ", ")})
+	}
+
+	fmt.Fprintf(c.stdout, "%s is %s\n", f.Name, f.State)
+	for _, v := range values {
+		line := fmt.Sprintf("  %-22s%s", v[0]+":", v[1])
+		fmt.Fprintln(c.stdout, strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// update shows a feature after it's modified.
+func (c *cli) update(featureName string, err error) error {
+	if err != nil {
+		return err
+	}
+	return c.show(featureName)
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func exportFeatures(c *cli, args []string) error {
+	if len(args) == 0 {
+		return export.Export(c.driver, c.stdout)
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := export.Export(c.driver, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func importFeatures(c *cli, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	replace := flags.Bool("replace", false, "remove the features and gate values that are not in the document")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("import accepts only one file")
+	}
+
+	mode := export.Merge
+	if *replace {
+		mode = export.Replace
+	}
+
+	var r io.Reader = c.stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return export.Import(c.driver, r, mode)
+}
+
+func actors(ids []string) []actor.Actor {
+	a := make([]actor.Actor, 0, len(ids))
+	for _, id := range ids {
+		a = append(a, actor.ID(id))
+	}
+	return a
+}
@@ -0,0 +1,178 @@
+// Command flipper manages feature flags stored with any registered driver.
+//
+// Usage:
+//
+//	flipper [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	list                                              list every feature and its state
+//	show <feature>                                    show the state and the gate values of a feature
+//	enable <feature>                                  enable a feature for everyone
+//	disable <feature>                                 disable a feature for everyone
+//	enable-actor <feature> <flipper id>...            enable a feature for actors, like User;1
+//	disable-actor <feature> <flipper id>...           disable a feature for actors
+//	enable-group <feature> <group>...                 enable a feature for groups
+//	disable-group <feature> <group>...                disable a feature for groups
+//	enable-percentage-of-actors <feature> <percent>   enable a feature for a percentage of actors
+//	disable-percentage-of-actors <feature>            disable the percentage of actors
+//	enable-percentage-of-time <feature> <percent>     enable a feature for a percentage of checks
+//	disable-percentage-of-time <feature>              disable the percentage of time
+//	export [file]                                     export every feature, to stdout by default
+//	import [-replace] [file]                          import features, from stdin by default
+//
+// The flags are:
+//
+//	-driver  name of the driver, like mongodb or redis (env FLIPPER_DRIVER)
+//	-config  driver configuration as a JSON object (env FLIPPER_CONFIG)
+//	-json    print the output as JSON
+//
+// For example:
+//
+//	FLIPPER_DRIVER=redis FLIPPER_CONFIG='{"url": "redis://127.0.0.1:6379/0"}' flipper enable search
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/pkg/errors"
+
+	// Drivers available in the command.
+	_ "github.com/calavera/go-flipper/driver/cache"
+	_ "github.com/calavera/go-flipper/driver/file"
+	_ "github.com/calavera/go-flipper/driver/http"
+	_ "github.com/calavera/go-flipper/driver/memory"
+	_ "github.com/calavera/go-flipper/driver/mongodb"
+	_ "github.com/calavera/go-flipper/driver/redis"
+	_ "github.com/calavera/go-flipper/driver/sql"
+	_ "github.com/calavera/go-flipper/driver/sync"
+
+	// Databases available for the sql driver.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// env holds the program's environment.
+type env struct {
+	getenv func(string) string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], env{
+		getenv: os.Getenv,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}))
+}
+
+func run(args []string, e env) int {
+	flags := flag.NewFlagSet("flipper", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: flipper [flags] <command> [arguments]\n\ncommands:\n")
+		for _, name := range commandNames() {
+			fmt.Fprintf(e.stderr, "  %s %s\n", name, commands[name].usage)
+		}
+		fmt.Fprintf(e.stderr, "\nflags:\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(e.stderr, "\ndrivers: %s\n", strings.Join(driver.Names(), ", "))
+	}
+
+	driverName := flags.String("driver", e.getenv("FLIPPER_DRIVER"), "name of the driver (env FLIPPER_DRIVER)")
+	config := flags.String("config", e.getenv("FLIPPER_CONFIG"), "driver configuration as a JSON object (env FLIPPER_CONFIG)")
+	jsonOutput := flags.Bool("json", false, "print the output as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(e.stderr, "flipper: unknown command %q\n", name)
+		flags.Usage()
+		return exitUsage
+	}
+
+	cmdArgs := flags.Args()[1:]
+	if err := cmd.validate(cmdArgs); err != nil {
+		fmt.Fprintf(e.stderr, "flipper: %v\nusage: flipper %s %s\n", err, name, cmd.usage)
+		return exitUsage
+	}
+
+	d, err := newDriver(*driverName, *config)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "flipper: %v\n", err)
+		return exitError
+	}
+
+	c := &cli{
+		env:    e,
+		driver: d,
+		client: client.NewClient(d),
+		json:   *jsonOutput,
+	}
+	if err := cmd.run(c, cmdArgs); err != nil {
+		fmt.Fprintf(e.stderr, "flipper: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+func newDriver(name, config string) (driver.Driver, error) {
+	if name == "" {
+		return nil, errors.New("missing driver, use -driver or FLIPPER_DRIVER")
+	}
+
+	factory := driver.Lookup(name)
+	if factory == nil {
+		return nil, errors.Errorf("unknown driver %q, available drivers: %s", name, strings.Join(driver.Names(), ", "))
+	}
+
+	var c map[string]interface{}
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), &c); err != nil {
+			return nil, errors.Wrap(err, "invalid driver configuration")
+		}
+	}
+
+	d, err := factory(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuration error for driver %s", name)
+	}
+	return d, nil
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// newFlipper returns a function that runs the command
+// with a file driver that writes features to a temporary file.
+func newFlipper(t *testing.T) (func(stdin string, args ...string) result, func()) {
+	dir, err := ioutil.TempDir("", "flipper-cli")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "features.json")
+	require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+
+	config, err := json.Marshal(map[string]interface{}{"path": path, "write_back": true})
+	require.NoError(t, err)
+
+	vars := map[string]string{
+		"FLIPPER_DRIVER": "file",
+		"FLIPPER_CONFIG": string(config),
+	}
+
+	run := func(stdin string, args ...string) result {
+		var stdout, stderr bytes.Buffer
+		code := run(args, env{
+			getenv: func(k string) string { return vars[k] },
+			stdin:  strings.NewReader(stdin),
+			stdout: &stdout,
+			stderr: &stderr,
+		})
+		return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+	}
+
+	return run, func() { os.RemoveAll(dir) }
+}
+
+func TestCommands(t *testing.T) {
+	flipper, cleanup := newFlipper(t)
+	defer cleanup()
+
+	t.Run("enable", func(t *testing.T) {
+		r := flipper("", "enable", "search")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Equal(t, `search is on
+  boolean:              true
+  actors:
+  groups:
+  percentage of actors: 0%
+  percentage of time:   0%
+`, r.stdout)
+
+		r = flipper("", "disable", "search")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Contains(t, r.stdout, "search is off\n")
+	})
+
+	t.Run("gates", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"enable-actor", "search", "User;1", "User;2"},
+			{"disable-actor", "search", "User;2"},
+			{"enable-group", "search", "admins", "staff"},
+			{"disable-group", "search", "staff"},
+			{"enable-percentage-of-actors", "search", "25"},
+			{"enable-percentage-of-time", "search", "12.5"},
+			{"enable-percentage-of-time", "checkout", "30"},
+			{"disable-percentage-of-time", "checkout"},
+		} {
+			r := flipper("", args...)
+			require.Equal(t, exitOK, r.code, r.stderr)
+		}
+
+		r := flipper("", "-json", "show", "search")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.JSONEq(t, `{
+			"name": "search",
+			"state": "conditional",
+			"boolean": false,
+			"actors": ["User;1"],
+			"groups": ["admins"],
+			"percentage_of_actors": 25,
+			"percentage_of_time": 12.5
+		}`, r.stdout)
+
+		r = flipper("", "show", "search")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Equal(t, `search is conditional
+  boolean:              false
+  actors:               User;1
+  groups:               admins
+  percentage of actors: 25%
+  percentage of time:   12.5%
+`, r.stdout)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		r := flipper("", "list")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Equal(t, "checkout  off\nsearch    conditional\n", r.stdout)
+
+		r = flipper("", "-json", "list")
+		require.Equal(t, exitOK, r.code, r.stderr)
+
+		var features []featureOutput
+		require.NoError(t, json.Unmarshal([]byte(r.stdout), &features))
+		require.Len(t, features, 2)
+		require.Equal(t, "checkout", features[0].Name)
+		require.Equal(t, []string{}, features[0].Actors)
+	})
+
+	t.Run("export and import", func(t *testing.T) {
+		r := flipper("", "export")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		exported := r.stdout
+		require.Contains(t, exported, `"version":1`)
+
+		doc := `{"version":1,"features":{"search":{"boolean":"true"},"signup":{"groups":["admins"]}}}`
+
+		r = flipper(doc, "import")
+		require.Equal(t, exitOK, r.code, r.stderr)
+
+		r = flipper("", "list")
+		require.Equal(t, "checkout  off\nsearch    on\nsignup    conditional\n", r.stdout)
+
+		r = flipper(exported, "import", "-replace")
+		require.Equal(t, exitOK, r.code, r.stderr)
+
+		r = flipper("", "list")
+		require.Equal(t, "checkout  off\nsearch    conditional\n", r.stdout)
+
+		dir, err := ioutil.TempDir("", "flipper-export")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "export.json")
+		r = flipper("", "export", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, exported, string(data))
+
+		r = flipper("", "import", "-replace", path)
+		require.Equal(t, exitOK, r.code, r.stderr)
+	})
+
+	t.Run("gates the ruby gem doesn't have", func(t *testing.T) {
+		doc := `{"version":1,"features":{"launch":{"schedule":{"start":"2018-11-01T00:00:00Z"},"ramp":{"start":"2018-11-01T00:00:00Z","duration":"72h0m0s","from":1,"to":100},"excluded_actors":["User;1"]}}}`
+		r := flipper(doc, "import")
+		require.Equal(t, exitOK, r.code, r.stderr)
+
+		r = flipper("", "show", "launch")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Equal(t, `launch is conditional
+  boolean:              false
+  actors:
+  groups:
+  percentage of actors: 0%
+  percentage of time:   0%
+  schedule:             from 2018-11-01T00:00:00Z
+  ramp:                 1% to 100% over 72h0m0s from 2018-11-01T00:00:00Z
+  excluded actors:      User;1
+`, r.stdout)
+
+		r = flipper("", "-json", "show", "launch")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Contains(t, r.stdout, `"schedule": {
+    "start": "2018-11-01T00:00:00Z"
+  }`)
+		require.Contains(t, r.stdout, `"ramp": {
+    "start": "2018-11-01T00:00:00Z",
+    "duration": "72h0m0s",
+    "from": 1,
+    "to": 100
+  }`)
+		require.Contains(t, r.stdout, `"excluded_actors": [
+    "User;1"
+  ]`)
+
+		r = flipper("", "export")
+		require.Equal(t, exitOK, r.code, r.stderr)
+		require.Contains(t, r.stdout, `"schedule":{"start":"2018-11-01T00:00:00Z"}`)
+		require.Contains(t, r.stdout, `"ramp":{"start":"2018-11-01T00:00:00Z","duration":"72h0m0s","from":1,"to":100}`)
+		require.Contains(t, r.stdout, `"excluded_actors":["User;1"]`)
+	})
+}
+
+func TestErrors(t *testing.T) {
+	flipper, cleanup := newFlipper(t)
+	defer cleanup()
+
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{nil, exitUsage, "usage: flipper"},
+		{[]string{"-unknown"}, exitUsage, "flag provided but not defined"},
+		{[]string{"toggle", "search"}, exitUsage, `unknown command "toggle"`},
+		{[]string{"show"}, exitUsage, "usage: flipper show <feature>"},
+		{[]string{"enable-actor", "search"}, exitUsage, "wrong number of arguments"},
+		{[]string{"enable-percentage-of-actors", "search", "12.5"}, exitError, `invalid percentage "12.5"`},
+		{[]string{"enable-percentage-of-time", "search", "110"}, exitError, `invalid percentage "110"`},
+		{[]string{"import", "missing.json"}, exitError, "missing.json"},
+		{[]string{"-driver", "unknown", "list"}, exitError, `unknown driver "unknown"`},
+		{[]string{"-config", "{", "list"}, exitError, "invalid driver configuration"},
+		{[]string{"-config", "{}", "list"}, exitError, "configuration error for driver file"},
+	}
+
+	for _, test := range tests {
+		r := flipper("", test.args...)
+		require.Equal(t, test.code, r.code, test.args)
+		require.Contains(t, r.stderr, test.expected, test.args)
+	}
+
+	r := flipper("{", "import")
+	require.Equal(t, exitError, r.code)
+	require.Contains(t, r.stderr, "invalid export document")
+}
@@ -0,0 +1,334 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTTL     = time.Minute
+	defaultMaxSize = 1000
+)
+
+type config struct {
+	Driver  string                 `mapstructure:"driver"`
+	Config  map[string]interface{} `mapstructure:"config"`
+	TTL     string                 `mapstructure:"ttl"`
+	MaxSize int                    `mapstructure:"max_size"`
+}
+
+// Stats holds the counters of a cache driver.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type entry struct {
+	feature string
+	key     string
+	gates   []gates.Gate
+	expires time.Time
+}
+
+// Driver is a store driver that caches the gates returned by another driver.
+// Entries expire after a TTL and the least recently used entries are evicted
+// when the cache is full. Enabling, disabling, clearing and removing features
+// through this driver invalidates their entries, changes made to the wrapped
+// source by other processes are visible after the TTL.
+// It's safe for concurrent use.
+type Driver struct {
+	// hits and misses are updated atomically,
+	// they are first to be 64-bit aligned.
+	hits   uint64
+	misses uint64
+
+	driver  driver.Driver
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu       sync.Mutex
+	lru      *list.List
+	entries  map[string]*list.Element
+	features map[string]map[string]*list.Element
+	// generation changes every time entries are invalidated,
+	// it prevents storing values read before an invalidation.
+	generation uint64
+}
+
+// NewDriver initializes a cache driver that wraps another driver.
+// It keeps up to maxSize entries for ttl.
+// Zero values use the defaults, one minute and 1000 entries.
+func NewDriver(d driver.Driver, ttl time.Duration, maxSize int) *Driver {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+
+	return &Driver{
+		driver:   d,
+		ttl:      ttl,
+		maxSize:  maxSize,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		features: make(map[string]map[string]*list.Element),
+	}
+}
+
+// Configure configures the wrapped driver.
+func (a *Driver) Configure(config map[string]interface{}) error {
+	return a.driver.Configure(config)
+}
+
+// Enable opens a feature for a give gate and invalidates its cache entries.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate and invalidates its cache entries.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	defer a.Invalidate(feature)
+	return driver.EnableContext(ctx, a.driver, feature, gate)
+}
+
+// Disable closes a feature for a given gate and invalidates its cache entries.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate and invalidates its cache entries.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	defer a.Invalidate(feature)
+	return driver.DisableContext(ctx, a.driver, feature, gate)
+}
+
+// Get returns the gates for a feature from the cache,
+// or from the wrapped driver if they are not cached or they expired.
+// Cached gates are shared between calls and they must not be modified.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.GetContext(context.Background(), feature, keys)
+}
+
+// GetContext returns the gates for a feature from the cache,
+// or from the wrapped driver if they are not cached or they expired.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k := cacheKey(feature, keys)
+
+	g, gen, ok := a.lookup(k)
+	if ok {
+		atomic.AddUint64(&a.hits, 1)
+		return g, nil
+	}
+	atomic.AddUint64(&a.misses, 1)
+
+	g, err := driver.GetContext(ctx, a.driver, feature, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	a.store(feature, k, g, gen)
+	return g, nil
+}
+
+// Features returns every feature known by the wrapped driver.
+// The list of features is not cached.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return nil, driver.ErrListingNotSupported
+	}
+	return l.Features()
+}
+
+// Add makes a feature known by the wrapped driver.
+func (a *Driver) Add(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	return l.Add(feature)
+}
+
+// Remove removes a feature from the wrapped driver and invalidates its cache entries.
+func (a *Driver) Remove(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	defer a.Invalidate(feature)
+	return l.Remove(feature)
+}
+
+// Clear removes every gate for a feature from the wrapped driver
+// and invalidates its cache entries.
+func (a *Driver) Clear(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	defer a.Invalidate(feature)
+	return l.Clear(feature)
+}
+
+// Invalidate removes every cache entry for a feature.
+func (a *Driver) Invalidate(feature feature.Feature) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.generation++
+	for _, e := range a.features[feature.Name] {
+		a.remove(e)
+	}
+}
+
+// Purge removes every cache entry.
+func (a *Driver) Purge() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.generation++
+	a.lru.Init()
+	a.entries = make(map[string]*list.Element)
+	a.features = make(map[string]map[string]*list.Element)
+}
+
+// Len returns the number of entries in the cache.
+func (a *Driver) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.lru.Len()
+}
+
+// Stats returns the number of cache hits and misses.
+func (a *Driver) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&a.hits),
+		Misses: atomic.LoadUint64(&a.misses),
+	}
+}
+
+func (a *Driver) lookup(k string) ([]gates.Gate, uint64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	e, ok := a.entries[k]
+	if !ok {
+		return nil, a.generation, false
+	}
+
+	en := e.Value.(*entry)
+	if a.now().After(en.expires) {
+		a.remove(e)
+		return nil, a.generation, false
+	}
+
+	a.lru.MoveToFront(e)
+	return en.gates, a.generation, true
+}
+
+func (a *Driver) store(feature feature.Feature, k string, g []gates.Gate, generation uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if generation != a.generation {
+		return
+	}
+
+	if e, ok := a.entries[k]; ok {
+		a.remove(e)
+	}
+
+	e := a.lru.PushFront(&entry{
+		feature: feature.Name,
+		key:     k,
+		gates:   g,
+		expires: a.now().Add(a.ttl),
+	})
+	a.entries[k] = e
+
+	f, ok := a.features[feature.Name]
+	if !ok {
+		f = make(map[string]*list.Element)
+		a.features[feature.Name] = f
+	}
+	f[k] = e
+
+	for a.lru.Len() > a.maxSize {
+		a.remove(a.lru.Back())
+	}
+}
+
+// remove deletes an entry from the cache.
+// The caller must hold the lock.
+func (a *Driver) remove(e *list.Element) {
+	en := a.lru.Remove(e).(*entry)
+	delete(a.entries, en.key)
+
+	f := a.features[en.feature]
+	delete(f, en.key)
+	if len(f) == 0 {
+		delete(a.features, en.feature)
+	}
+}
+
+func cacheKey(feature feature.Feature, keys []gates.GateKey) string {
+	k := make([]string, 0, len(keys)+1)
+	k = append(k, feature.Name)
+	for _, g := range keys {
+		k = append(k, string(g))
+	}
+	return strings.Join(k, "\x00")
+}
+
+// init registers the driver with the name "cache".
+// These are the options for this driver:
+//   - driver: name of the registered driver to cache (required)
+//   - config: configuration for the cached driver (optional)
+//   - ttl: duration of the entries, like "30s" (optional - default "1m")
+//   - max_size: maximum number of entries (optional - default 1000)
+func init() {
+	driver.Register("cache", func(c map[string]interface{}) (driver.Driver, error) {
+		var conf config
+		if err := mapstructure.Decode(c, &conf); err != nil {
+			return nil, errors.Wrap(err, "error decoding cache's driver configuration")
+		}
+
+		factory := driver.Lookup(conf.Driver)
+		if factory == nil {
+			return nil, errors.Errorf("invalid driver to cache: %q", conf.Driver)
+		}
+
+		var ttl time.Duration
+		if conf.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(conf.TTL); err != nil {
+				return nil, errors.Wrap(err, "invalid TTL for cache's driver")
+			}
+		}
+
+		d, err := factory(conf.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewDriver(d, ttl, conf.MaxSize), nil
+	})
+}
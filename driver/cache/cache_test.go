@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	flipper "github.com/calavera/go-flipper"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+var keys = []gates.GateKey{gates.BoolGateKey, gates.ActorGateKey}
+
+// countingDriver counts the number of times GetContext is called.
+type countingDriver struct {
+	*memory.Driver
+	mu   sync.Mutex
+	gets int
+}
+
+func (d *countingDriver) GetContext(ctx context.Context, f feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	d.mu.Lock()
+	d.gets++
+	d.mu.Unlock()
+	return d.Driver.GetContext(ctx, f, keys)
+}
+
+func (d *countingDriver) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.gets
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{Driver: memory.NewDriver()}
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		return NewDriver(memory.NewDriver(), time.Minute, 10)
+	})
+}
+
+func TestCache(t *testing.T) {
+	feat := feature.NewFeature("test")
+
+	t.Run("hits and misses", func(t *testing.T) {
+		upstream := newCountingDriver()
+		d := NewDriver(upstream, time.Minute, 10)
+
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+
+		for i := 0; i < 3; i++ {
+			g, err := d.Get(feat, keys)
+			require.NoError(t, err)
+			require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+		}
+
+		require.Equal(t, 1, upstream.count())
+		require.Equal(t, Stats{Hits: 2, Misses: 1}, d.Stats())
+
+		_, err := d.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Equal(t, 2, upstream.count())
+		require.Equal(t, 2, d.Len())
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		upstream := newCountingDriver()
+		d := NewDriver(upstream, time.Minute, 10)
+
+		_, err := d.Get(feat, keys)
+		require.NoError(t, err)
+		_, err = d.Get(feature.NewFeature("other"), keys)
+		require.NoError(t, err)
+
+		require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet("User;1"))))
+		require.Equal(t, 1, d.Len())
+
+		g, err := d.Get(feat, keys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewActorGate(gates.NewSet("User;1"))}, g)
+
+		require.NoError(t, d.Disable(feat, gates.NewActorGate(gates.NewSet("User;1"))))
+
+		g, err = d.Get(feat, keys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+		require.Equal(t, 4, upstream.count())
+
+		d.Purge()
+		require.Equal(t, 0, d.Len())
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		upstream := newCountingDriver()
+		d := NewDriver(upstream, time.Second, 10)
+
+		now := time.Now()
+		d.now = func() time.Time { return now }
+
+		_, err := d.Get(feat, keys)
+		require.NoError(t, err)
+		_, err = d.Get(feat, keys)
+		require.NoError(t, err)
+		require.Equal(t, 1, upstream.count())
+
+		now = now.Add(2 * time.Second)
+
+		_, err = d.Get(feat, keys)
+		require.NoError(t, err)
+		require.Equal(t, 2, upstream.count())
+	})
+
+	t.Run("max size", func(t *testing.T) {
+		upstream := newCountingDriver()
+		d := NewDriver(upstream, time.Minute, 2)
+
+		a := feature.NewFeature("a")
+		b := feature.NewFeature("b")
+		c := feature.NewFeature("c")
+
+		for _, f := range []feature.Feature{a, b, a, c} {
+			_, err := d.Get(f, keys)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, d.Len())
+		require.Equal(t, 3, upstream.count())
+
+		// b was the least recently used entry.
+		_, err := d.Get(a, keys)
+		require.NoError(t, err)
+		require.Equal(t, 3, upstream.count())
+
+		_, err = d.Get(b, keys)
+		require.NoError(t, err)
+		require.Equal(t, 4, upstream.count())
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		d := NewDriver(memory.NewDriver(), time.Minute, 5)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				f := feature.NewFeature(string(rune('a' + i%8)))
+				for j := 0; j < 100; j++ {
+					require.NoError(t, d.Enable(f, gates.NewBoolGate(true)))
+					_, err := d.Get(f, keys)
+					require.NoError(t, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	c, err := flipper.NewClient("cache", map[string]interface{}{
+		"driver":   "memory",
+		"ttl":      "30s",
+		"max_size": 10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Enable("test"))
+
+	enabled, err := c.IsEnabled("test")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	_, err = flipper.NewClient("cache", map[string]interface{}{"driver": "unknown"})
+	require.Error(t, err)
+}
@@ -1,16 +1,37 @@
 package driver
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/calavera/go-flipper/feature"
 	"github.com/calavera/go-flipper/gates"
 )
 
-var registry = make(map[string]Driver)
+// ErrListingNotSupported is returned when a driver
+// doesn't implement the Lister interface.
+var ErrListingNotSupported = errors.New("the driver doesn't support listing features")
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Factory creates a new driver configured with
+// the given configuration. Every call must return
+// an independent driver, so clients don't share stores
+// unless the configuration points to the same source.
+type Factory func(config map[string]interface{}) (Driver, error)
 
 // Driver defines how flipper gets information
 // from a source. This source can be a database,
 // an http endpoint or anything that implements
 // this interface.
+// Enabling the boolean gate clears every other gate for a feature,
+// and disabling it removes every gate, the same way the Ruby adapters do.
 type Driver interface {
 	Configure(config map[string]interface{}) error
 	Enable(feature feature.Feature, gate gates.Gate) error
@@ -18,15 +39,183 @@ type Driver interface {
 	Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error)
 }
 
-// Init stores an driver by name to be used
-// by a client. This allows drivers to self
-// register themselves on initialization
+// ContextDriver defines how flipper gets information
+// from a source honoring the cancellation and deadline
+// of a context. Drivers that implement this interface
+// can abort slow operations when the context is done.
+type ContextDriver interface {
+	Driver
+	EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error
+	DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error
+	GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error)
+}
+
+// Lister defines how flipper keeps track of
+// the features stored in a source.
+// Drivers that implement this interface can be
+// used to enumerate and manage the features they store.
+type Lister interface {
+	// Features returns every feature known by the source.
+	Features() ([]feature.Feature, error)
+	// Add makes a feature known by the source without enabling it.
+	Add(feature feature.Feature) error
+	// Remove clears every gate for a feature and forgets about it.
+	Remove(feature feature.Feature) error
+	// Clear removes every gate value for a feature, but keeps it known by the source.
+	Clear(feature feature.Feature) error
+}
+
+// MultiGetter defines how flipper gets the gates
+// of many features in a single round-trip to a source.
+type MultiGetter interface {
+	// GetMulti returns the gates for every feature given a set of gate keys,
+	// indexed by feature name. Features without gates can be missing from the result.
+	GetMulti(features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error)
+	// GetMultiContext is like GetMulti, honoring the cancellation and deadline of a context.
+	GetMultiContext(ctx context.Context, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error)
+}
+
+// Register makes a driver factory available by name.
+// This allows drivers to self register themselves on initialization.
+// It panics if the factory is nil or if Register is called twice with the same name.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if f == nil {
+		panic("flipper: Register driver factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("flipper: Register called twice for driver %s", name))
+	}
+	registry[name] = f
+}
+
+// Unregister removes a driver factory from the registry.
+// It doesn't do anything if the driver is not registered.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, name)
+}
+
+// Init stores a driver instance by name.
+// Every client created with this name shares the same instance,
+// which is configured again every time.
+//
+// Deprecated: use Register to create independent drivers for each client.
 func Init(name string, a Driver) {
-	registry[name] = a
+	Unregister(name)
+	Register(name, func(config map[string]interface{}) (Driver, error) {
+		if err := a.Configure(config); err != nil {
+			return nil, err
+		}
+		return a, nil
+	})
 }
 
-// Get retrieves a driver from the registry by its name.
+// Lookup retrieves a driver factory from the registry by its name.
 // It returns nil if the driver doesn't exist.
-func Get(name string) Driver {
+func Lookup(name string) Factory {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	return registry[name]
 }
+
+// Get creates a driver with the factory registered by name, without configuration.
+// It returns nil if the driver doesn't exist, or if it can't be created
+// without configuration.
+//
+// Deprecated: use Lookup to get the factory and create a configured driver.
+func Get(name string) Driver {
+	f := Lookup(name)
+	if f == nil {
+		return nil
+	}
+
+	d, err := f(nil)
+	if err != nil {
+		return nil
+	}
+	return d
+}
+
+// Names returns a sorted list of the names of the registered drivers.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnableContext opens a feature for a gate using the driver's
+// ContextDriver implementation when it's available.
+// Other drivers are only called if the context is not done yet.
+func EnableContext(ctx context.Context, d Driver, feature feature.Feature, gate gates.Gate) error {
+	if cd, ok := d.(ContextDriver); ok {
+		return cd.EnableContext(ctx, feature, gate)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Enable(feature, gate)
+}
+
+// DisableContext closes a feature for a gate using the driver's
+// ContextDriver implementation when it's available.
+// Other drivers are only called if the context is not done yet.
+func DisableContext(ctx context.Context, d Driver, feature feature.Feature, gate gates.Gate) error {
+	if cd, ok := d.(ContextDriver); ok {
+		return cd.DisableContext(ctx, feature, gate)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Disable(feature, gate)
+}
+
+// GetContext returns the gates for a feature using the driver's
+// ContextDriver implementation when it's available.
+// Other drivers are only called if the context is not done yet.
+func GetContext(ctx context.Context, d Driver, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	if cd, ok := d.(ContextDriver); ok {
+		return cd.GetContext(ctx, feature, keys)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return d.Get(feature, keys)
+}
+
+// GetMultiContext returns the gates for many features, indexed by feature name,
+// using the driver's MultiGetter implementation when it's available.
+// Other drivers are called once for every feature.
+// Drivers are only called if the context is not done yet.
+func GetMultiContext(ctx context.Context, d Driver, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if md, ok := d.(MultiGetter); ok {
+		return md.GetMultiContext(ctx, features, keys)
+	}
+
+	result := make(map[string][]gates.Gate, len(features))
+	for _, f := range features {
+		g, err := GetContext(ctx, d, f, keys)
+		if err != nil {
+			return nil, err
+		}
+		if len(g) > 0 {
+			result[f.Name] = g
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	factory := func(config map[string]interface{}) (driver.Driver, error) {
+		return memory.NewDriver(), nil
+	}
+
+	driver.Register("testing", factory)
+	defer driver.Unregister("testing")
+
+	require.Contains(t, driver.Names(), "testing")
+	require.Contains(t, driver.Names(), "memory")
+
+	f := driver.Lookup("testing")
+	require.NotNil(t, f)
+
+	a, err := f(nil)
+	require.NoError(t, err)
+	b, err := f(nil)
+	require.NoError(t, err)
+	require.False(t, a == b)
+
+	require.Panics(t, func() {
+		driver.Register("testing", factory)
+	})
+	require.Panics(t, func() {
+		driver.Register("nil", nil)
+	})
+
+	driver.Unregister("testing")
+	require.Nil(t, driver.Lookup("testing"))
+	require.NotContains(t, driver.Names(), "testing")
+}
+
+func TestGet(t *testing.T) {
+	require.IsType(t, &memory.Driver{}, driver.Get("memory"))
+	require.False(t, driver.Get("memory") == driver.Get("memory"))
+	require.Nil(t, driver.Get("unknown"))
+
+	driver.Register("failing", func(config map[string]interface{}) (driver.Driver, error) {
+		return nil, errors.New("missing configuration")
+	})
+	defer driver.Unregister("failing")
+	require.Nil(t, driver.Get("failing"))
+}
+
+func TestInit(t *testing.T) {
+	shared := memory.NewDriver()
+	driver.Init("shared", shared)
+	defer driver.Unregister("shared")
+
+	a, err := driver.Lookup("shared")(nil)
+	require.NoError(t, err)
+	require.True(t, a == driver.Driver(shared))
+
+	driver.Init("shared", memory.NewDriver())
+	b, err := driver.Lookup("shared")(nil)
+	require.NoError(t, err)
+	require.False(t, b == driver.Driver(shared))
+}
@@ -0,0 +1,294 @@
+// Package drivertest provides a conformance suite for store drivers.
+// Every driver must behave the same way for the client to give
+// the same answers regardless of where features are stored.
+package drivertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+var allKeys = []gates.GateKey{
+	gates.BoolGateKey,
+	gates.ActorGateKey,
+	gates.GroupGateKey,
+	gates.PercentageOfActorsGateKey,
+	gates.PercentageOfTimeGateKey,
+	gates.ScheduleGateKey,
+	gates.RampGateKey,
+	gates.ExclusionGateKey,
+}
+
+// RunConformance runs the conformance suite against the drivers returned by newDriver.
+// newDriver is called once per test and it must return a configured driver
+// with an empty store.
+// The Lister, MultiGetter and ContextDriver behaviors are only checked when the driver implements them.
+func RunConformance(t *testing.T, newDriver func(t *testing.T) driver.Driver) {
+	feat := feature.NewFeature("conformance")
+
+	t.Run("unknown feature", func(t *testing.T) {
+		d := newDriver(t)
+
+		g, err := d.Get(feat, allKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		require.NoError(t, d.Disable(feat, gates.NewBoolGate(false)))
+		require.NoError(t, d.Disable(feat, gates.NewActorGate(gates.NewSet("1"))))
+		require.NoError(t, d.Disable(feat, gates.NewPercentageOfActorsGate(0)))
+
+		g, err = d.Get(feat, allKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	t.Run("boolean gate", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+		requireGates(t, d, feat, gates.NewBoolGate(true))
+
+		require.NoError(t, d.Disable(feat, gates.NewBoolGate(false)))
+		requireGates(t, d, feat)
+	})
+
+	t.Run("actor gate", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet("User;1"))))
+		require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet("User;1", "User;2"))))
+		requireGates(t, d, feat, gates.NewActorGate(gates.NewSet("User;1", "User;2")))
+
+		require.NoError(t, d.Disable(feat, gates.NewActorGate(gates.NewSet("User;1", "User;3"))))
+		requireGates(t, d, feat, gates.NewActorGate(gates.NewSet("User;2")))
+
+		require.NoError(t, d.Disable(feat, gates.NewActorGate(gates.NewSet("User;2"))))
+		requireGates(t, d, feat)
+	})
+
+	t.Run("group gate", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewGroupGate(gates.NewSet("admins"))))
+		require.NoError(t, d.Enable(feat, gates.NewGroupGate(gates.NewSet("admins", "staff"))))
+		requireGates(t, d, feat, gates.NewGroupGate(gates.NewSet("admins", "staff")))
+
+		require.NoError(t, d.Disable(feat, gates.NewGroupGate(gates.NewSet("admins"))))
+		requireGates(t, d, feat, gates.NewGroupGate(gates.NewSet("staff")))
+
+		require.NoError(t, d.Disable(feat, gates.NewGroupGate(gates.NewSet("staff"))))
+		requireGates(t, d, feat)
+	})
+
+	t.Run("percentage of actors gate", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfActorsGate(10)))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfActorsGate(30)))
+		requireGates(t, d, feat, gates.NewPercentageOfActorsGate(30))
+
+		require.NoError(t, d.Disable(feat, gates.NewPercentageOfActorsGate(0)))
+		requireGates(t, d, feat)
+	})
+
+	t.Run("percentage of time gate", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfTimeGate(10)))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfTimeGate(30)))
+		requireGates(t, d, feat, gates.NewPercentageOfTimeGate(30))
+
+		require.NoError(t, d.Enable(feat, gates.NewFractionalPercentageOfTimeGate(0.5)))
+		requireGates(t, d, feat, gates.NewFractionalPercentageOfTimeGate(0.5))
+
+		require.NoError(t, d.Disable(feat, gates.NewPercentageOfTimeGate(0)))
+		requireGates(t, d, feat)
+	})
+
+	t.Run("boolean gate clears", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet("User;1"))))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfActorsGate(10)))
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+		requireGates(t, d, feat, gates.NewBoolGate(true))
+
+		require.NoError(t, d.Enable(feat, gates.NewGroupGate(gates.NewSet("admins"))))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfTimeGate(20)))
+		requireGates(t, d, feat,
+			gates.NewBoolGate(true),
+			gates.NewGroupGate(gates.NewSet("admins")),
+			gates.NewPercentageOfTimeGate(20))
+
+		require.NoError(t, d.Disable(feat, gates.NewBoolGate(false)))
+		requireGates(t, d, feat)
+
+		other := feature.NewFeature("conformance_other")
+		g, err := d.Get(other, allKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	t.Run("requested keys", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfTimeGate(20)))
+
+		keys := []gates.GateKey{gates.PercentageOfTimeGateKey, gates.ActorGateKey, gates.BoolGateKey}
+		g, err := d.Get(feat, keys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewPercentageOfTimeGate(20), gates.NewBoolGate(true)}, g)
+	})
+
+	t.Run("lister", func(t *testing.T) {
+		d := newDriver(t)
+
+		l, ok := d.(driver.Lister)
+		if !ok {
+			t.Skip("driver doesn't implement driver.Lister")
+		}
+
+		f, err := l.Features()
+		require.NoError(t, err)
+		require.Len(t, f, 0)
+
+		search := feature.NewFeature("search")
+		require.NoError(t, l.Add(search))
+		require.NoError(t, l.Add(search))
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+
+		f, err = l.Features()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []feature.Feature{search, feat}, f)
+
+		require.NoError(t, l.Clear(feat))
+		requireGates(t, d, feat)
+
+		f, err = l.Features()
+		require.NoError(t, err)
+		require.Len(t, f, 2)
+
+		require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet("User;1"))))
+		require.NoError(t, l.Remove(feat))
+		requireGates(t, d, feat)
+
+		f, err = l.Features()
+		require.NoError(t, err)
+		require.Equal(t, []feature.Feature{search}, f)
+	})
+
+	t.Run("multi getter", func(t *testing.T) {
+		d := newDriver(t)
+
+		md, ok := d.(driver.MultiGetter)
+		if !ok {
+			t.Skip("driver doesn't implement driver.MultiGetter")
+		}
+
+		other := feature.NewFeature("conformance_other")
+		unknown := feature.NewFeature("conformance_unknown")
+
+		require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+		require.NoError(t, d.Enable(feat, gates.NewPercentageOfTimeGate(20)))
+		require.NoError(t, d.Enable(other, gates.NewActorGate(gates.NewSet("User;1"))))
+
+		keys := []gates.GateKey{gates.PercentageOfTimeGateKey, gates.ActorGateKey, gates.BoolGateKey}
+		g, err := md.GetMulti([]feature.Feature{feat, other, unknown}, keys)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]gates.Gate{
+			feat.Name:  {gates.NewPercentageOfTimeGate(20), gates.NewBoolGate(true)},
+			other.Name: {gates.NewActorGate(gates.NewSet("User;1"))},
+		}, g)
+
+		g, err = md.GetMulti(nil, keys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		ctx := context.Background()
+		g, err = md.GetMultiContext(ctx, []feature.Feature{other}, keys)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]gates.Gate{other.Name: {gates.NewActorGate(gates.NewSet("User;1"))}}, g)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err = md.GetMultiContext(ctx, []feature.Feature{feat, other}, keys)
+		require.Error(t, err)
+	})
+
+	t.Run("context driver", func(t *testing.T) {
+		d := newDriver(t)
+
+		cd, ok := d.(driver.ContextDriver)
+		if !ok {
+			t.Skip("driver doesn't implement driver.ContextDriver")
+		}
+
+		ctx := context.Background()
+		require.NoError(t, cd.EnableContext(ctx, feat, gates.NewBoolGate(true)))
+
+		g, err := cd.GetContext(ctx, feat, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err = cd.GetContext(ctx, feat, allKeys)
+		require.Error(t, err)
+		require.Error(t, cd.DisableContext(ctx, feat, gates.NewBoolGate(false)))
+
+		requireGates(t, d, feat, gates.NewBoolGate(true))
+	})
+}
+
+// RunGateConformance checks that a driver stores a gate that
+// not every driver supports, like gates.ScheduleGate.
+// The gate is enabled and then disabled with the disabled gate,
+// the feature must not have any gate after that.
+// The boolean gate is always enabled before the gate,
+// because enabling the boolean gate clears other gates.
+// newDriver follows the same rules as in RunConformance.
+func RunGateConformance(t *testing.T, newDriver func(t *testing.T) driver.Driver, enabled, disabled gates.Gate) {
+	feat := feature.NewFeature("conformance")
+	d := newDriver(t)
+
+	require.NoError(t, d.Enable(feat, enabled))
+	require.NoError(t, d.Enable(feat, enabled))
+	requireGates(t, d, feat, enabled)
+
+	require.NoError(t, d.Disable(feat, disabled))
+	requireGates(t, d, feat)
+
+	require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+	require.NoError(t, d.Enable(feat, enabled))
+	g, err := d.Get(feat, []gates.GateKey{enabled.Key(), gates.BoolGateKey})
+	require.NoError(t, err)
+	require.Equal(t, []gates.Gate{enabled, gates.NewBoolGate(true)}, g)
+
+	require.NoError(t, d.Disable(feat, disabled))
+	requireGates(t, d, feat, gates.NewBoolGate(true))
+
+	require.NoError(t, d.Disable(feat, gates.NewBoolGate(false)))
+	requireGates(t, d, feat)
+}
+
+// requireGates checks that the driver returns exactly the expected gates for a feature,
+// in the same order as the gate keys are defined in allKeys.
+func requireGates(t *testing.T, d driver.Driver, f feature.Feature, expected ...gates.Gate) {
+	g, err := d.Get(f, allKeys)
+	require.NoError(t, err)
+
+	if len(expected) == 0 {
+		require.Len(t, g, 0)
+		return
+	}
+	require.Equal(t, expected, g)
+}
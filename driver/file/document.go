@@ -0,0 +1,515 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const featuresKey = "features"
+
+var allKeys = []gates.GateKey{
+	gates.BoolGateKey,
+	gates.ActorGateKey,
+	gates.GroupGateKey,
+	gates.PercentageOfActorsGateKey,
+	gates.PercentageOfTimeGateKey,
+	gates.ScheduleGateKey,
+	gates.RampGateKey,
+	gates.ExclusionGateKey,
+}
+
+// supported checks if the file format includes a gate.
+func supported(key gates.GateKey) bool {
+	for _, k := range allKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+type format int
+
+const (
+	formatJSON format = iota
+	formatYAML
+)
+
+// formatFor returns the format of a file based on its extension.
+func formatFor(path string) (format, error) {
+	switch filepath.Ext(path) {
+	case ".json":
+		return formatJSON, nil
+	case ".yml", ".yaml":
+		return formatYAML, nil
+	default:
+		return 0, errors.Errorf("unsupported file format for %s, use .json, .yml or .yaml", path)
+	}
+}
+
+// featureGates holds the gate values of a feature in a file.
+type featureGates struct {
+	Boolean            bool
+	Actors             []string
+	Groups             []string
+	PercentageOfActors int
+	PercentageOfTime   float64
+	Schedule           *schedule
+	Ramp               *ramp
+	ExcludedActors     []string
+}
+
+// schedule is the time window of a schedule gate, zero times are open ended.
+type schedule struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ramp is the value of a ramp gate.
+type ramp struct {
+	Start    time.Time
+	Duration time.Duration
+	From     int
+	To       int
+}
+
+// decode parses and validates a document.
+// Empty documents don't have any feature.
+func decode(data []byte, f format) (map[string]featureGates, error) {
+	features := make(map[string]featureGates)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return features, nil
+	}
+
+	var doc interface{}
+	var err error
+	if f == formatJSON {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid document")
+	}
+	if doc == nil {
+		return features, nil
+	}
+
+	root, ok := stringMap(doc)
+	if !ok {
+		return nil, errors.New("invalid document: expected an object with the key \"features\"")
+	}
+	for k := range root {
+		if k != featuresKey {
+			return nil, errors.Errorf("invalid document: unknown key %q", k)
+		}
+	}
+
+	if root[featuresKey] == nil {
+		return features, nil
+	}
+	list, ok := stringMap(root[featuresKey])
+	if !ok {
+		return nil, errors.New("invalid document: \"features\" must be an object with a key per feature")
+	}
+
+	for _, name := range sortedKeys(list) {
+		if name == "" {
+			return nil, errors.New("invalid document: feature names cannot be empty")
+		}
+
+		fg, err := decodeFeature(list[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid feature %q", name)
+		}
+		features[name] = fg
+	}
+
+	return features, nil
+}
+
+func decodeFeature(v interface{}) (featureGates, error) {
+	var fg featureGates
+	if v == nil {
+		return fg, nil
+	}
+
+	values, ok := stringMap(v)
+	if !ok {
+		return fg, errors.New("expected an object with a key per gate")
+	}
+
+	for _, k := range sortedKeys(values) {
+		v := values[k]
+		var err error
+
+		switch gates.GateKey(k) {
+		case gates.BoolGateKey:
+			b, ok := v.(bool)
+			if !ok {
+				err = errors.Errorf("%s must be true or false", k)
+			}
+			fg.Boolean = b
+		case gates.ActorGateKey:
+			fg.Actors, err = stringList(k, v)
+		case gates.GroupGateKey:
+			fg.Groups, err = stringList(k, v)
+		case gates.PercentageOfActorsGateKey:
+			fg.PercentageOfActors, err = integerPercentage(k, v)
+		case gates.PercentageOfTimeGateKey:
+			fg.PercentageOfTime, err = percentage(k, v)
+		case gates.ScheduleGateKey:
+			fg.Schedule, err = decodeSchedule(k, v)
+		case gates.RampGateKey:
+			fg.Ramp, err = decodeRamp(k, v)
+		case gates.ExclusionGateKey:
+			fg.ExcludedActors, err = stringList(k, v)
+		default:
+			err = errors.Errorf("unknown gate %q", k)
+		}
+
+		if err != nil {
+			return fg, err
+		}
+	}
+
+	return fg, nil
+}
+
+// decodeSchedule parses a schedule with optional start and end times in RFC 3339 format.
+func decodeSchedule(k string, v interface{}) (*schedule, error) {
+	values, ok := stringMap(v)
+	if !ok {
+		return nil, errors.Errorf("%s must be an object with start and end times", k)
+	}
+
+	var s schedule
+	var err error
+	for _, name := range sortedKeys(values) {
+		switch name {
+		case "start":
+			s.Start, err = timeValue(k+" start", values[name])
+		case "end":
+			s.End, err = timeValue(k+" end", values[name])
+		default:
+			err = errors.Errorf("unknown %s value %q", k, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.Start.IsZero() && !s.End.IsZero() && !s.End.After(s.Start) {
+		return nil, errors.Errorf("%s must end after it starts", k)
+	}
+	return &s, nil
+}
+
+// decodeRamp parses a ramp with a start time in RFC 3339 format,
+// a duration in the format of time.ParseDuration and two integer percentages.
+func decodeRamp(k string, v interface{}) (*ramp, error) {
+	values, ok := stringMap(v)
+	if !ok {
+		return nil, errors.Errorf("%s must be an object with start, duration, from and to values", k)
+	}
+
+	var r ramp
+	var err error
+	for _, name := range sortedKeys(values) {
+		switch name {
+		case "start":
+			r.Start, err = timeValue(k+" start", values[name])
+		case "duration":
+			d, _ := values[name].(string)
+			r.Duration, err = time.ParseDuration(d)
+			if err != nil || r.Duration < 0 {
+				err = errors.Errorf("%s duration must be a positive duration, found %v", k, values[name])
+			}
+		case "from":
+			r.From, err = integerPercentage(k+" from", values[name])
+		case "to":
+			r.To, err = integerPercentage(k+" to", values[name])
+		default:
+			err = errors.Errorf("unknown %s value %q", k, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.From > r.To {
+		return nil, errors.Errorf("%s must grow, found from %d to %d", k, r.From, r.To)
+	}
+	return &r, nil
+}
+
+// encode serializes features in the same format decode reads.
+// Gates that are not set are omitted.
+func encode(features map[string]featureGates, f format) ([]byte, error) {
+	list := make(map[string]map[string]interface{}, len(features))
+	for name, fg := range features {
+		values := make(map[string]interface{})
+		if fg.Boolean {
+			values[string(gates.BoolGateKey)] = true
+		}
+		if len(fg.Actors) > 0 {
+			values[string(gates.ActorGateKey)] = fg.Actors
+		}
+		if len(fg.Groups) > 0 {
+			values[string(gates.GroupGateKey)] = fg.Groups
+		}
+		if fg.PercentageOfActors > 0 {
+			values[string(gates.PercentageOfActorsGateKey)] = fg.PercentageOfActors
+		}
+		if fg.PercentageOfTime > 0 {
+			values[string(gates.PercentageOfTimeGateKey)] = fg.PercentageOfTime
+		}
+		if fg.Schedule != nil {
+			window := make(map[string]interface{})
+			if !fg.Schedule.Start.IsZero() {
+				window["start"] = formatTime(fg.Schedule.Start)
+			}
+			if !fg.Schedule.End.IsZero() {
+				window["end"] = formatTime(fg.Schedule.End)
+			}
+			values[string(gates.ScheduleGateKey)] = window
+		}
+		if fg.Ramp != nil {
+			r := map[string]interface{}{
+				"duration": fg.Ramp.Duration.String(),
+				"from":     fg.Ramp.From,
+				"to":       fg.Ramp.To,
+			}
+			if !fg.Ramp.Start.IsZero() {
+				r["start"] = formatTime(fg.Ramp.Start)
+			}
+			values[string(gates.RampGateKey)] = r
+		}
+		if len(fg.ExcludedActors) > 0 {
+			values[string(gates.ExclusionGateKey)] = fg.ExcludedActors
+		}
+		list[name] = values
+	}
+
+	doc := map[string]interface{}{featuresKey: list}
+	if f == formatJSON {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// load creates a memory driver with the features from a document.
+func load(features map[string]featureGates) (*memory.Driver, error) {
+	d := memory.NewDriver()
+
+	for name, fg := range features {
+		f := feature.NewFeature(name)
+		if err := d.Add(f); err != nil {
+			return nil, err
+		}
+
+		var g []gates.Gate
+		if fg.Boolean {
+			g = append(g, gates.NewBoolGate(true))
+		}
+		if len(fg.Actors) > 0 {
+			g = append(g, gates.NewActorGate(gates.NewSet(fg.Actors...)))
+		}
+		if len(fg.Groups) > 0 {
+			g = append(g, gates.NewGroupGate(gates.NewSet(fg.Groups...)))
+		}
+		if fg.PercentageOfActors > 0 {
+			g = append(g, gates.NewPercentageOfActorsGate(fg.PercentageOfActors))
+		}
+		if fg.PercentageOfTime > 0 {
+			g = append(g, gates.NewFractionalPercentageOfTimeGate(fg.PercentageOfTime))
+		}
+		if fg.Schedule != nil {
+			g = append(g, gates.NewScheduleGate(fg.Schedule.Start, fg.Schedule.End))
+		}
+		if fg.Ramp != nil {
+			g = append(g, gates.NewRampGate(fg.Ramp.Start, fg.Ramp.Duration, fg.Ramp.From, fg.Ramp.To))
+		}
+		if len(fg.ExcludedActors) > 0 {
+			g = append(g, gates.NewExclusionGate(gates.NewSet(fg.ExcludedActors...)))
+		}
+
+		for _, gate := range g {
+			if err := d.Enable(f, gate); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// snapshot reads every feature from a memory driver.
+func snapshot(d *memory.Driver) (map[string]featureGates, error) {
+	list, err := d.Features()
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]featureGates, len(list))
+	for _, f := range list {
+		g, err := d.Get(f, allKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		var fg featureGates
+		for _, gate := range g {
+			switch v := gate.(type) {
+			case gates.BoolGateType:
+				fg.Boolean = v.BoolValue()
+			case gates.SetGateType:
+				switch gate.Key() {
+				case gates.ActorGateKey:
+					fg.Actors = sortedValues(v.SetValue())
+				case gates.GroupGateKey:
+					fg.Groups = sortedValues(v.SetValue())
+				case gates.ExclusionGateKey:
+					fg.ExcludedActors = sortedValues(v.SetValue())
+				}
+			case gates.IntGateType:
+				if gate.Key() == gates.PercentageOfActorsGateKey {
+					fg.PercentageOfActors = v.IntValue()
+				} else {
+					fg.PercentageOfTime = gates.NumberValue(v)
+				}
+			case gates.TimeRangeGateType:
+				start, end := v.TimeRange()
+				fg.Schedule = &schedule{Start: start, End: end}
+			case gates.RampGateType:
+				start, duration, from, to := v.Ramp()
+				fg.Ramp = &ramp{Start: start, Duration: duration, From: from, To: to}
+			}
+		}
+		features[f.Name] = fg
+	}
+
+	return features, nil
+}
+
+// stringMap converts JSON and YAML objects to maps with string keys.
+func stringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		s := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, false
+			}
+			s[ks] = v
+		}
+		return s, true
+	default:
+		return nil, false
+	}
+}
+
+func stringList(k string, v interface{}) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s must be a list of strings", k)
+	}
+
+	s := make([]string, 0, len(l))
+	for _, i := range l {
+		is, ok := i.(string)
+		if !ok || is == "" {
+			return nil, errors.Errorf("%s must be a list of strings, found %v", k, i)
+		}
+		s = append(s, is)
+	}
+	return s, nil
+}
+
+func percentage(k string, v interface{}) (float64, error) {
+	var p float64
+	switch n := v.(type) {
+	case int:
+		p = float64(n)
+	case float64:
+		p = n
+	default:
+		return 0, errors.Errorf("%s must be a number between 0 and 100", k)
+	}
+
+	if p < 0 || p > 100 {
+		return 0, errors.Errorf("%s must be a number between 0 and 100, found %v", k, v)
+	}
+	return p, nil
+}
+
+func integerPercentage(k string, v interface{}) (int, error) {
+	p, err := percentage(k, v)
+	if err == nil && p != math.Trunc(p) {
+		err = errors.Errorf("%s must be an integer", k)
+	}
+	return int(p), err
+}
+
+// timeValue parses times in RFC 3339 format.
+// YAML documents can also have timestamps.
+func timeValue(k string, v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return t.UTC(), nil
+	case string:
+		p, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, errors.Errorf("%s must be a time in RFC 3339 format, found %q", k, t)
+		}
+		return p.UTC(), nil
+	default:
+		return time.Time{}, errors.Errorf("%s must be a time in RFC 3339 format", k)
+	}
+}
+
+// formatTime formats times in RFC 3339 format, in UTC.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	k := make([]string, 0, len(m))
+	for i := range m {
+		k = append(k, i)
+	}
+	sort.Strings(k)
+	return k
+}
+
+func sortedValues(s gates.Set) []string {
+	v := make([]string, 0, len(s))
+	for k := range s {
+		v = append(v, k)
+	}
+	sort.Strings(v)
+	return v
+}
@@ -0,0 +1,416 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const defaultInterval = time.Second
+
+// ErrReadOnly is returned when features are modified
+// through a driver that doesn't write them back to its file.
+var ErrReadOnly = errors.New("the file driver is read only, enable write back to modify features")
+
+type config struct {
+	Path      string `mapstructure:"path"`
+	Interval  string `mapstructure:"interval"`
+	WriteBack bool   `mapstructure:"write_back"`
+}
+
+// Driver is a store driver that reads features from a JSON or YAML file.
+// The file has an object with the key "features", and an object
+// for each feature with the values of its gates:
+//
+//	features:
+//	  search:
+//	    boolean: true
+//	    actors: ["User;1"]
+//	    groups: ["admins"]
+//	    percentage_of_actors: 25
+//	    percentage_of_time: 12.5
+//	    schedule:
+//	      start: 2018-11-01T00:00:00Z
+//	      end: 2018-11-02T00:00:00Z
+//	    ramp:
+//	      start: 2018-11-01T00:00:00Z
+//	      duration: 72h
+//	      from: 1
+//	      to: 100
+//	    excluded_actors: ["User;2"]
+//
+// The format is chosen by the file extension: .json, .yml or .yaml.
+// Features are kept in memory and they are replaced all at once
+// when the file changes, while the driver is started.
+// Features can only be modified when the driver writes them back to the file.
+// It's safe for concurrent use.
+type Driver struct {
+	path      string
+	format    format
+	interval  time.Duration
+	writeBack bool
+
+	mu        sync.RWMutex
+	local     *memory.Driver
+	modTime   time.Time
+	size      int64
+	lastError error
+
+	// writeMu serializes reloads and writes to the file.
+	writeMu sync.Mutex
+
+	runMu sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewDriver initializes a driver that reads features from a file.
+// The file is loaded right away, an error is returned if it's not valid.
+// The file is checked for changes every interval after the driver is started,
+// a zero interval uses the default, one second.
+// When writeBack is true, features modified through the driver are written to the file,
+// otherwise ErrReadOnly is returned.
+func NewDriver(path string, interval time.Duration, writeBack bool) (*Driver, error) {
+	f, err := formatFor(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	d := &Driver{
+		path:      path,
+		format:    f,
+		interval:  interval,
+		writeBack: writeBack,
+	}
+
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Configure configures the file driver.
+// This driver is configured when it's initialized, so this is a NOOP.
+func (a *Driver) Configure(config map[string]interface{}) error {
+	return nil
+}
+
+// Start watches the file for changes in the background.
+// It doesn't do anything if the driver is already started.
+func (a *Driver) Start() {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.stop != nil {
+		return
+	}
+
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.run(a.stop, a.done)
+}
+
+// Stop stops watching the file and waits for the watcher to finish.
+// It doesn't do anything if the driver is not started.
+func (a *Driver) Stop() {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.stop == nil {
+		return
+	}
+
+	close(a.stop)
+	<-a.done
+	a.stop = nil
+	a.done = nil
+}
+
+// Reload reads the file and replaces the features with its content.
+// The features are not modified if the file is not valid.
+func (a *Driver) Reload() error {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+
+	err := a.reload()
+
+	a.mu.Lock()
+	a.lastError = err
+	a.mu.Unlock()
+
+	return err
+}
+
+// LastError returns the error of the last time the file was loaded.
+// It returns nil if the file was loaded successfully.
+func (a *Driver) LastError() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.lastError
+}
+
+// Enable opens a feature for a give gate and writes it to the file.
+// It returns an error for gates that the file format doesn't include.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	if !supported(gate.Key()) {
+		return errors.Errorf("unsupported data type: %v", gate.Key())
+	}
+	return a.write(func(d *memory.Driver) error {
+		return d.Enable(feature, gate)
+	})
+}
+
+// Disable closes a feature for a given gate and writes it to the file.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.write(func(d *memory.Driver) error {
+		return d.Disable(feature, gate)
+	})
+}
+
+// Get returns the enabled gates for a feature given a set of gate keys.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.localDriver().Get(feature, keys)
+}
+
+// EnableContext opens a feature for a give gate if the context is not done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Enable(feature, gate)
+}
+
+// DisableContext closes a feature for a given gate if the context is not done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Disable(feature, gate)
+}
+
+// GetContext returns the enabled gates for a feature if the context is not done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.localDriver().GetContext(ctx, feature, keys)
+}
+
+// Features returns every feature in the file.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	return a.localDriver().Features()
+}
+
+// Add adds a feature to the file without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	return a.write(func(d *memory.Driver) error {
+		return d.Add(feature)
+	})
+}
+
+// Remove removes a feature from the file.
+func (a *Driver) Remove(feature feature.Feature) error {
+	return a.write(func(d *memory.Driver) error {
+		return d.Remove(feature)
+	})
+}
+
+// Clear removes every gate value for a feature from the file.
+func (a *Driver) Clear(feature feature.Feature) error {
+	return a.write(func(d *memory.Driver) error {
+		return d.Clear(feature)
+	})
+}
+
+func (a *Driver) localDriver() *memory.Driver {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.local
+}
+
+// reload loads the file.
+// The caller must hold the write lock.
+func (a *Driver) reload() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return errors.Wrap(err, "error reading features file")
+	}
+
+	data, err := ioutil.ReadFile(a.path)
+	if err != nil {
+		return errors.Wrap(err, "error reading features file")
+	}
+
+	features, err := decode(data, a.format)
+	if err != nil {
+		return errors.Wrap(err, a.path)
+	}
+
+	local, err := load(features)
+	if err != nil {
+		return err
+	}
+
+	a.swap(local, info)
+	return nil
+}
+
+// write applies a change to a copy of the features,
+// writes them to the file and replaces the current features.
+// The features are not modified if the file cannot be written.
+func (a *Driver) write(f func(d *memory.Driver) error) error {
+	if !a.writeBack {
+		return ErrReadOnly
+	}
+
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+
+	features, err := snapshot(a.localDriver())
+	if err != nil {
+		return err
+	}
+
+	next, err := load(features)
+	if err != nil {
+		return err
+	}
+
+	if err := f(next); err != nil {
+		return err
+	}
+
+	if features, err = snapshot(next); err != nil {
+		return err
+	}
+
+	data, err := encode(features, a.format)
+	if err != nil {
+		return err
+	}
+
+	info, err := a.save(data)
+	if err != nil {
+		return errors.Wrap(err, "error writing features file")
+	}
+
+	a.swap(next, info)
+	return nil
+}
+
+// save replaces the file atomically, writing the data
+// to a temporary file and renaming it.
+func (a *Driver) save(data []byte) (os.FileInfo, error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(a.path); err == nil {
+		mode = info.Mode()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(a.path), "."+filepath.Base(a.path))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), a.path); err != nil {
+		return nil, err
+	}
+
+	return os.Stat(a.path)
+}
+
+func (a *Driver) swap(local *memory.Driver, info os.FileInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.local = local
+	a.modTime = info.ModTime()
+	a.size = info.Size()
+}
+
+// changed checks if the file was modified since it was loaded.
+func (a *Driver) changed() bool {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		// let reload record the error.
+		return true
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return !info.ModTime().Equal(a.modTime) || info.Size() != a.size
+}
+
+func (a *Driver) run(stop, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if a.changed() {
+				a.Reload()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// init registers the driver with the name "file".
+// These are the options for this driver:
+//   - path: path to the JSON or YAML file (required)
+//   - interval: time between checks for changes, like "5s" (optional - default "1s")
+//   - write_back: whether to write modified features to the file (optional - default false)
+//
+// Drivers created by the registry are started right away.
+func init() {
+	driver.Register("file", func(c map[string]interface{}) (driver.Driver, error) {
+		var conf config
+		if err := mapstructure.Decode(c, &conf); err != nil {
+			return nil, errors.Wrap(err, "error decoding file's driver configuration")
+		}
+
+		var interval time.Duration
+		if conf.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(conf.Interval); err != nil {
+				return nil, errors.Wrap(err, "invalid interval for file's driver")
+			}
+		}
+
+		d, err := NewDriver(conf.Path, interval, conf.WriteBack)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Start()
+		return d, nil
+	})
+}
@@ -0,0 +1,268 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlFeatures = `
+features:
+  search:
+    boolean: true
+    actors: ["User;1", "User;2"]
+    groups:
+      - admins
+    percentage_of_actors: 25
+    percentage_of_time: 12.5
+  checkout:
+`
+
+const jsonFeatures = `{
+  "features": {
+    "search": {
+      "boolean": true,
+      "actors": ["User;1", "User;2"],
+      "groups": ["admins"],
+      "percentage_of_actors": 25,
+      "percentage_of_time": 12.5
+    },
+    "checkout": {}
+  }
+}`
+
+func writeFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "flipper-file")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		path := writeFile(t, "features.json", "")
+		d, err := NewDriver(path, 0, true)
+		require.NoError(t, err)
+		return d
+	})
+}
+
+func TestGateConformance(t *testing.T) {
+	newDriver := func(t *testing.T) driver.Driver {
+		path := writeFile(t, "features.json", "")
+		d, err := NewDriver(path, 0, true)
+		require.NoError(t, err)
+		return d
+	}
+
+	t.Run("schedule gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewScheduleGate(start, start.Add(time.Hour)),
+			gates.NewScheduleGate(time.Time{}, time.Time{}))
+	})
+
+	t.Run("ramp gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewRampGate(start, 72*time.Hour, 1, 100),
+			gates.NewRampGate(time.Time{}, 0, 0, 0))
+	})
+
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
+}
+
+func TestFile(t *testing.T) {
+	search := feature.NewFeature("search")
+	all := []gates.Gate{
+		gates.NewBoolGate(true),
+		gates.NewActorGate(gates.NewSet("User;1", "User;2")),
+		gates.NewGroupGate(gates.NewSet("admins")),
+		gates.NewPercentageOfActorsGate(25),
+		gates.NewFractionalPercentageOfTimeGate(12.5),
+	}
+
+	t.Run("load features", func(t *testing.T) {
+		for name, content := range map[string]string{"features.yml": yamlFeatures, "features.json": jsonFeatures} {
+			t.Run(name, func(t *testing.T) {
+				path := writeFile(t, name, content)
+				defer os.RemoveAll(filepath.Dir(path))
+
+				d, err := NewDriver(path, 0, false)
+				require.NoError(t, err)
+
+				g, err := d.Get(search, allKeys)
+				require.NoError(t, err)
+				require.Equal(t, all, g)
+
+				f, err := d.Features()
+				require.NoError(t, err)
+				require.ElementsMatch(t, []feature.Feature{search, feature.NewFeature("checkout")}, f)
+			})
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		tests := map[string]string{
+			`features: [search]`:                                 `"features" must be an object`,
+			`flags: {}`:                                          `unknown key "flags"`,
+			`features: {search: {boolean: "yes"}}`:               `invalid feature "search": boolean must be true or false`,
+			`features: {search: {actors: "User;1"}}`:             `invalid feature "search": actors must be a list of strings`,
+			`features: {search: {groups: [1]}}`:                  `invalid feature "search": groups must be a list of strings`,
+			`features: {search: {percentage_of_actors: 12.5}}`:   `invalid feature "search": percentage_of_actors must be an integer`,
+			`features: {search: {percentage_of_time: 101}}`:      `invalid feature "search": percentage_of_time must be a number between 0 and 100`,
+			`features: {search: {}, checkout: {percentage: 10}}`: `invalid feature "checkout": unknown gate "percentage"`,
+			`features: {search: true}`:                           `invalid feature "search": expected an object`,
+			`features: {search: {schedule: {start: tomorrow}}}`:  `invalid feature "search": schedule start must be a time in RFC 3339 format`,
+			`features: {search: {schedule: {start: "2018-11-02T00:00:00Z", end: "2018-11-01T00:00:00Z"}}}`: `invalid feature "search": schedule must end after it starts`,
+			`features: {search: {excluded_actors: [1]}}`:                                                   `invalid feature "search": excluded_actors must be a list of strings`,
+			`features: {search: {ramp: {duration: soon}}}`:                                                 `invalid feature "search": ramp duration must be a positive duration`,
+			`features: {search: {ramp: {from: 1.5}}}`:                                                      `invalid feature "search": ramp from must be an integer`,
+			`features: {search: {ramp: {from: 50, to: 10}}}`:                                               `invalid feature "search": ramp must grow`,
+			`features: {search: {boolean: true}`:                                                           `invalid document`,
+		}
+
+		for content, expected := range tests {
+			path := writeFile(t, "features.yaml", content)
+			_, err := NewDriver(path, 0, false)
+			require.Error(t, err, content)
+			require.Contains(t, err.Error(), path)
+			require.Contains(t, err.Error(), expected)
+			os.RemoveAll(filepath.Dir(path))
+		}
+
+		_, err := NewDriver("features.toml", 0, false)
+		require.Error(t, err)
+
+		_, err = NewDriver("missing.json", 0, false)
+		require.Error(t, err)
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		path := writeFile(t, "features.json", jsonFeatures)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		d, err := NewDriver(path, 0, false)
+		require.NoError(t, err)
+
+		require.Equal(t, ErrReadOnly, d.Enable(search, gates.NewBoolGate(true)))
+		require.Equal(t, ErrReadOnly, d.Disable(search, gates.NewBoolGate(false)))
+		require.Equal(t, ErrReadOnly, d.Add(search))
+		require.Equal(t, ErrReadOnly, d.Remove(search))
+		require.Equal(t, ErrReadOnly, d.Clear(search))
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, jsonFeatures, string(data))
+	})
+
+	t.Run("write back", func(t *testing.T) {
+		for name, content := range map[string]string{"features.yml": yamlFeatures, "features.json": jsonFeatures} {
+			t.Run(name, func(t *testing.T) {
+				path := writeFile(t, name, content)
+				defer os.RemoveAll(filepath.Dir(path))
+
+				d, err := NewDriver(path, 0, true)
+				require.NoError(t, err)
+
+				require.NoError(t, d.Disable(search, gates.NewBoolGate(false)))
+				require.NoError(t, d.Enable(search, gates.NewActorGate(gates.NewSet("User;3"))))
+				require.NoError(t, d.Remove(feature.NewFeature("checkout")))
+
+				start := time.Date(2018, 11, 1, 12, 30, 0, 500, time.UTC)
+				require.NoError(t, d.Enable(search, gates.NewScheduleGate(start, time.Time{})))
+				require.NoError(t, d.Enable(search, gates.NewRampGate(start, 90*time.Minute, 5, 50)))
+				require.NoError(t, d.Enable(search, gates.NewExclusionGate(gates.NewSet("User;4"))))
+
+				// disabling the boolean gate removed the other gates.
+				expected := []gates.Gate{
+					gates.NewActorGate(gates.NewSet("User;3")),
+					gates.NewScheduleGate(start, time.Time{}),
+					gates.NewRampGate(start, 90*time.Minute, 5, 50),
+					gates.NewExclusionGate(gates.NewSet("User;4")),
+				}
+
+				g, err := d.Get(search, allKeys)
+				require.NoError(t, err)
+				require.Equal(t, expected, g)
+
+				d, err = NewDriver(path, 0, false)
+				require.NoError(t, err)
+
+				g, err = d.Get(search, allKeys)
+				require.NoError(t, err)
+				require.Equal(t, expected, g)
+
+				f, err := d.Features()
+				require.NoError(t, err)
+				require.Equal(t, []feature.Feature{search}, f)
+			})
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		path := writeFile(t, "features.yml", yamlFeatures)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		d, err := NewDriver(path, 10*time.Millisecond, false)
+		require.NoError(t, err)
+
+		d.Start()
+		d.Start()
+		defer d.Stop()
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`features: {search: {boolean: true}}`), 0644))
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			g, err := d.Get(search, allKeys)
+			require.NoError(t, err)
+			if len(g) == 1 {
+				break
+			}
+			require.True(t, time.Now().Before(deadline), "features were not reloaded")
+			time.Sleep(5 * time.Millisecond)
+		}
+		require.NoError(t, d.LastError())
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`features: {search: {boolean: 1}}`), 0644))
+		require.Error(t, d.Reload())
+		require.Error(t, d.LastError())
+
+		g, err := d.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+	})
+}
+
+func TestRegister(t *testing.T) {
+	path := writeFile(t, "features.json", jsonFeatures)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := flipper.NewClient("file", map[string]interface{}{"path": path, "interval": "soon"})
+	require.Error(t, err)
+
+	c, err := flipper.NewClient("file", map[string]interface{}{"path": path, "interval": "1h"})
+	require.NoError(t, err)
+
+	ok, err := c.IsEnabled("search")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, ErrReadOnly, c.Enable("checkout"))
+}
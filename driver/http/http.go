@@ -0,0 +1,506 @@
+// Package http provides a store driver that manages features
+// through a remote flipper-api endpoint, like the one served by the api package.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calavera/go-flipper/api"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout   = 5 * time.Second
+	defaultRetryWait = 100 * time.Millisecond
+)
+
+type config struct {
+	URL       string            `mapstructure:"url"`
+	Headers   map[string]string `mapstructure:"headers"`
+	Timeout   string            `mapstructure:"timeout"`
+	Retries   int               `mapstructure:"retries"`
+	RetryWait string            `mapstructure:"retry_wait"`
+}
+
+// Driver is a store driver that reads and modifies features
+// with the flipper-api JSON protocol.
+// Requests that fail because of network errors or server errors
+// are retried, every gate change is safe to apply more than once.
+// It's safe for concurrent use after it's configured.
+type Driver struct {
+	client    *nethttp.Client
+	baseURL   string
+	headers   nethttp.Header
+	retries   int
+	retryWait time.Duration
+}
+
+// NewDriver initializes a new HTTP driver.
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+// NewDriverWithClient initializes a new HTTP driver with a given HTTP client.
+// This factory allows you to reuse a client with its own transport and timeout.
+func NewDriverWithClient(c *nethttp.Client) *Driver {
+	return &Driver{client: c}
+}
+
+// Configure configures the HTTP driver.
+// These are the options for this driver:
+//   - url: string url where the API is mounted, like https://example.com/flipper/api (required)
+//   - headers: map of headers added to every request, like {"Authorization": "Bearer token"} (optional)
+//   - timeout: time limit for each request, like "2s" (optional - default "5s")
+//     It's ignored when the driver is initialized with a client.
+//   - retries: number of times a failed request is retried (optional - default 0)
+//   - retry_wait: time to wait before the first retry, it grows with every retry (optional - default "100ms")
+func (a *Driver) Configure(c map[string]interface{}) error {
+	var conf config
+	if err := mapstructure.Decode(c, &conf); err != nil {
+		return errors.Wrap(err, "error decoding HTTP's driver configuration")
+	}
+
+	u, err := url.Parse(conf.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid URL for HTTP's driver: %q", conf.URL)
+	}
+
+	if conf.Retries < 0 {
+		return errors.New("invalid number of retries for HTTP's driver")
+	}
+
+	timeout, err := parseDuration(conf.Timeout, defaultTimeout)
+	if err != nil {
+		return errors.Wrap(err, "invalid timeout for HTTP's driver")
+	}
+
+	retryWait, err := parseDuration(conf.RetryWait, defaultRetryWait)
+	if err != nil {
+		return errors.Wrap(err, "invalid retry wait for HTTP's driver")
+	}
+
+	headers := nethttp.Header{}
+	for k, v := range conf.Headers {
+		headers.Set(k, v)
+	}
+
+	if a.client == nil {
+		a.client = &nethttp.Client{Timeout: timeout}
+	}
+	a.baseURL = strings.TrimRight(u.String(), "/")
+	a.headers = headers
+	a.retries = conf.Retries
+	a.retryWait = retryWait
+
+	return nil
+}
+
+// Enable opens a feature for a give gate.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate.
+// Set gates send one request for each value.
+// It stops waiting for the API when the context is done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.update(ctx, nethttp.MethodPost, feature, gate)
+}
+
+// Disable closes a feature for a given gate.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate.
+// Set gates send one request for each value.
+// It stops waiting for the API when the context is done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.update(ctx, nethttp.MethodDelete, feature, gate)
+}
+
+// Get returns the enabled gates for a feature given a set of gate keys.
+// Gates are skipped if they are not set for a feature.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.GetContext(context.Background(), feature, keys)
+}
+
+// GetContext returns the enabled gates for a feature given a set of gate keys.
+// It stops waiting for the API when the context is done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	var f api.Feature
+	err := a.do(ctx, nethttp.MethodGet, featurePath(feature.Name), nil, &f)
+	if isFeatureNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[gates.GateKey]interface{}, len(f.Gates))
+	for _, g := range f.Gates {
+		values[gates.GateKey(g.Key)] = g.Value
+	}
+
+	var g []gates.Gate
+	for _, k := range keys {
+		gate, err := newGate(k, values[k])
+		if err != nil {
+			return nil, err
+		}
+		if gate != nil {
+			g = append(g, gate)
+		}
+	}
+
+	return g, nil
+}
+
+// Features returns every feature known by the API.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	var list api.Features
+	if err := a.do(context.Background(), nethttp.MethodGet, "/features", nil, &list); err != nil {
+		return nil, err
+	}
+
+	f := make([]feature.Feature, 0, len(list.Features))
+	for _, l := range list.Features {
+		f = append(f, feature.NewFeature(l.Key))
+	}
+	return f, nil
+}
+
+// Add makes a feature known by the API without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	params := url.Values{"name": {feature.Name}}
+	return a.do(context.Background(), nethttp.MethodPost, "/features", params, nil)
+}
+
+// Remove clears every gate for a feature and forgets about it.
+func (a *Driver) Remove(feature feature.Feature) error {
+	return a.do(context.Background(), nethttp.MethodDelete, featurePath(feature.Name), nil, nil)
+}
+
+// Clear removes every gate value for a feature.
+func (a *Driver) Clear(feature feature.Feature) error {
+	return a.do(context.Background(), nethttp.MethodDelete, featurePath(feature.Name)+"/clear", nil, nil)
+}
+
+// update sends the requests to enable or disable a gate.
+func (a *Driver) update(ctx context.Context, method string, feature feature.Feature, gate gates.Gate) error {
+	path := featurePath(feature.Name) + "/" + url.PathEscape(string(gate.Key()))
+
+	if g, ok := gate.(gates.IntGateType); ok {
+		var params url.Values
+		if method == nethttp.MethodPost {
+			v := gates.NumberValue(g)
+			params = url.Values{"percentage": {strconv.FormatFloat(v, 'f', -1, 64)}}
+		}
+		return a.do(ctx, method, path, params, nil)
+	} else if _, ok := gate.(gates.BoolGateType); ok {
+		return a.do(ctx, method, path, nil, nil)
+	} else if g, ok := gate.(gates.SetGateType); ok {
+		param := "name"
+		if gate.Key() == gates.ActorGateKey || gate.Key() == gates.ExclusionGateKey {
+			param = "flipper_id"
+		}
+		for v := range g.SetValue() {
+			if err := a.do(ctx, method, path, url.Values{param: {v}}, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	} else if g, ok := gate.(gates.TimeRangeGateType); ok {
+		params := url.Values{}
+		if method == nethttp.MethodPost {
+			start, end := g.TimeRange()
+			setTime(params, "start", start)
+			setTime(params, "end", end)
+		}
+		return a.do(ctx, method, path, params, nil)
+	} else if g, ok := gate.(gates.RampGateType); ok {
+		params := url.Values{}
+		if method == nethttp.MethodPost {
+			start, duration, from, to := g.Ramp()
+			setTime(params, "start", start)
+			params.Set("duration", duration.String())
+			params.Set("from", strconv.Itoa(from))
+			params.Set("to", strconv.Itoa(to))
+		}
+		return a.do(ctx, method, path, params, nil)
+	}
+
+	return errors.Errorf("unsupported data type: %v", gate.Key())
+}
+
+// do sends a request to the API and decodes the response in out, when it's not nil.
+// The request is retried if it fails with a network error or a server error.
+func (a *Driver) do(ctx context.Context, method, path string, params url.Values, out interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = a.send(ctx, method, path, params, out)
+		if !retry || attempt >= a.retries {
+			break
+		}
+
+		t := time.NewTimer(a.retryWait * time.Duration(attempt+1))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	return err
+}
+
+// send sends a single request, it returns whether the request can be retried.
+func (a *Driver) send(ctx context.Context, method, path string, params url.Values, out interface{}) (bool, error) {
+	var body io.Reader
+	if len(params) > 0 {
+		body = strings.NewReader(params.Encode())
+	}
+
+	req, err := nethttp.NewRequest(method, a.baseURL+path, body)
+	if err != nil {
+		return false, errors.Wrap(err, "error creating request to the flipper API")
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range a.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, errors.Wrap(err, "error sending request to the flipper API")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return res.StatusCode >= 500, responseError(res)
+	}
+
+	if out == nil {
+		io.Copy(ioutil.Discard, res.Body)
+		return false, nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return false, errors.Wrap(err, "error decoding response from the flipper API")
+	}
+	return false, nil
+}
+
+// responseError returns the API error in the response body,
+// wrapped with the response status.
+func responseError(res *nethttp.Response) error {
+	var e api.Error
+	if err := json.NewDecoder(res.Body).Decode(&e); err != nil || e.Message == "" {
+		e = api.Error{Message: nethttp.StatusText(res.StatusCode)}
+	}
+	return errors.Wrapf(e, "flipper API responded with status %d", res.StatusCode)
+}
+
+func isFeatureNotFound(err error) bool {
+	e, ok := errors.Cause(err).(api.Error)
+	return ok && e.Code == api.ErrFeatureNotFound.Code
+}
+
+// newGate builds a gate from a value in the API response.
+// It returns nil if the gate is not set.
+func newGate(key gates.GateKey, value interface{}) (gates.Gate, error) {
+	switch key {
+	case gates.BoolGateKey:
+		b, err := boolValue(value)
+		if err != nil || !b {
+			return nil, err
+		}
+		return gates.NewBoolGate(true), nil
+	case gates.ActorGateKey, gates.GroupGateKey, gates.ExclusionGateKey:
+		s, err := setValue(value)
+		if err != nil || len(s) == 0 {
+			return nil, err
+		}
+		switch key {
+		case gates.ActorGateKey:
+			return gates.NewActorGate(s), nil
+		case gates.ExclusionGateKey:
+			return gates.NewExclusionGate(s), nil
+		}
+		return gates.NewGroupGate(s), nil
+	case gates.PercentageOfActorsGateKey, gates.PercentageOfTimeGateKey:
+		n, err := numberValue(value)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if key == gates.PercentageOfActorsGateKey {
+			return gates.NewFractionalPercentageOfActorsGate(n), nil
+		}
+		return gates.NewFractionalPercentageOfTimeGate(n), nil
+	case gates.ScheduleGateKey:
+		if value == nil {
+			return nil, nil
+		}
+		window, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("unexpected schedule value in API response: %v", value)
+		}
+		start, err := timeValue(window["start"])
+		if err != nil {
+			return nil, err
+		}
+		end, err := timeValue(window["end"])
+		if err != nil {
+			return nil, err
+		}
+		return gates.NewScheduleGate(start, end), nil
+	case gates.RampGateKey:
+		if value == nil {
+			return nil, nil
+		}
+		ramp, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("unexpected ramp value in API response: %v", value)
+		}
+		start, err := timeValue(ramp["start"])
+		if err != nil {
+			return nil, err
+		}
+		d, _ := ramp["duration"].(string)
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return nil, errors.Errorf("unexpected duration value in API response: %v", ramp["duration"])
+		}
+		from, err := numberValue(ramp["from"])
+		if err != nil {
+			return nil, err
+		}
+		to, err := numberValue(ramp["to"])
+		if err != nil {
+			return nil, err
+		}
+		return gates.NewRampGate(start, duration, int(from), int(to)), nil
+	default:
+		// the API doesn't have other gates, they are never set.
+		return nil, nil
+	}
+}
+
+// boolValue decodes boolean values, the Ruby API can send them as strings.
+func boolValue(v interface{}) (bool, error) {
+	switch t := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return t, nil
+	case string:
+		return t == "true", nil
+	default:
+		return false, errors.Errorf("unexpected bool value in API response: %v", v)
+	}
+}
+
+func setValue(v interface{}) (gates.Set, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected set value in API response: %v", v)
+	}
+
+	s := gates.Set{}
+	for _, i := range list {
+		str, ok := i.(string)
+		if !ok {
+			return nil, errors.Errorf("unexpected set value in API response: %v", v)
+		}
+		s[str] = str
+	}
+	return s, nil
+}
+
+// numberValue decodes numeric values, the Ruby API can send them as strings.
+func numberValue(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return t, nil
+	case string:
+		if t == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return 0, errors.Errorf("unexpected number value in API response: %v", v)
+		}
+		return n, nil
+	default:
+		return 0, errors.Errorf("unexpected number value in API response: %v", v)
+	}
+}
+
+// timeValue decodes times in RFC 3339 format, missing times are zero.
+func timeValue(v interface{}) (time.Time, error) {
+	if v == nil {
+		return time.Time{}, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, errors.Errorf("unexpected time value in API response: %v", v)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("unexpected time value in API response: %v", v)
+	}
+	return t, nil
+}
+
+// setTime adds a time parameter in RFC 3339 format, unless it's zero.
+func setTime(params url.Values, k string, t time.Time) {
+	if !t.IsZero() {
+		params.Set(k, t.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+func featurePath(name string) string {
+	return "/features/" + url.PathEscape(name)
+}
+
+func parseDuration(s string, d time.Duration) (time.Duration, error) {
+	if s == "" {
+		return d, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func init() {
+	driver.Register("http", func(config map[string]interface{}) (driver.Driver, error) {
+		d := NewDriver()
+		if err := d.Configure(config); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
+}
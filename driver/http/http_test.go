@@ -0,0 +1,249 @@
+package http
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/api"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts an API server that keeps features in memory.
+// The middleware function can intercept requests before they reach the API.
+func newServer(middleware func(w nethttp.ResponseWriter, r *nethttp.Request) bool) *httptest.Server {
+	handler := nethttp.StripPrefix("/flipper/api", api.NewHandler(client.NewClient(memory.NewDriver())))
+
+	return httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if middleware != nil && !middleware(w, r) {
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func newDriver(t *testing.T, server *httptest.Server, config map[string]interface{}) *Driver {
+	c := map[string]interface{}{"url": server.URL + "/flipper/api/"}
+	for k, v := range config {
+		c[k] = v
+	}
+
+	d := NewDriver()
+	require.NoError(t, d.Configure(c))
+	return d
+}
+
+func TestConformance(t *testing.T) {
+	gates.RegisterGroup("admins", func(a actor.Actor) bool { return false })
+	gates.RegisterGroup("staff", func(a actor.Actor) bool { return false })
+
+	var servers []*httptest.Server
+	defer func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}()
+
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		s := newServer(nil)
+		servers = append(servers, s)
+		return newDriver(t, s, nil)
+	})
+}
+
+func TestGateConformance(t *testing.T) {
+	var servers []*httptest.Server
+	defer func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}()
+
+	newConformanceDriver := func(t *testing.T) driver.Driver {
+		s := newServer(nil)
+		servers = append(servers, s)
+		return newDriver(t, s, nil)
+	}
+
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newConformanceDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
+
+	t.Run("schedule gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newConformanceDriver,
+			gates.NewScheduleGate(start, start.Add(time.Hour)),
+			gates.NewScheduleGate(time.Time{}, time.Time{}))
+	})
+
+	t.Run("open ended schedule gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newConformanceDriver,
+			gates.NewScheduleGate(start, time.Time{}),
+			gates.NewScheduleGate(time.Time{}, time.Time{}))
+	})
+
+	t.Run("ramp gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newConformanceDriver,
+			gates.NewRampGate(start, 72*time.Hour, 1, 100),
+			gates.NewRampGate(time.Time{}, 0, 0, 0))
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	server := newServer(func(w nethttp.ResponseWriter, r *nethttp.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return false
+		}
+		return true
+	})
+	defer server.Close()
+
+	search := feature.NewFeature("search")
+
+	d := newDriver(t, server, nil)
+	err := d.Enable(search, gates.NewBoolGate(true))
+	require.EqualError(t, err, "flipper API responded with status 401: Unauthorized")
+
+	d = newDriver(t, server, map[string]interface{}{
+		"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+	})
+	require.NoError(t, d.Enable(search, gates.NewBoolGate(true)))
+
+	g, err := d.Get(search, []gates.GateKey{gates.BoolGateKey})
+	require.NoError(t, err)
+	require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+}
+
+func TestRetries(t *testing.T) {
+	var failures int32
+	server := newServer(func(w nethttp.ResponseWriter, r *nethttp.Request) bool {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+			return false
+		}
+		return true
+	})
+	defer server.Close()
+
+	search := feature.NewFeature("search")
+
+	t.Run("server errors", func(t *testing.T) {
+		atomic.StoreInt32(&failures, 2)
+
+		d := newDriver(t, server, map[string]interface{}{"retries": 1, "retry_wait": "1ms"})
+		err := d.Enable(search, gates.NewBoolGate(true))
+		require.EqualError(t, err, "flipper API responded with status 503: Service Unavailable")
+
+		atomic.StoreInt32(&failures, 2)
+
+		d = newDriver(t, server, map[string]interface{}{"retries": 2, "retry_wait": "1ms"})
+		require.NoError(t, d.Enable(search, gates.NewBoolGate(true)))
+	})
+
+	t.Run("client errors", func(t *testing.T) {
+		atomic.StoreInt32(&failures, 0)
+
+		var requests int32
+		server := newServer(func(w nethttp.ResponseWriter, r *nethttp.Request) bool {
+			atomic.AddInt32(&requests, 1)
+			return true
+		})
+		defer server.Close()
+
+		d := newDriver(t, server, map[string]interface{}{"retries": 3, "retry_wait": "1ms"})
+		err := d.Enable(search, gates.NewGroupGate(gates.NewSet("unregistered")))
+		require.Error(t, err)
+		require.Equal(t, api.ErrGroupNotRegistered, errors.Cause(err))
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	server := newServer(func(w nethttp.ResponseWriter, r *nethttp.Request) bool {
+		time.Sleep(200 * time.Millisecond)
+		return true
+	})
+	defer server.Close()
+
+	d := newDriver(t, server, map[string]interface{}{"timeout": "20ms"})
+	_, err := d.Get(feature.NewFeature("search"), []gates.GateKey{gates.BoolGateKey})
+	require.Error(t, err)
+}
+
+func TestResponseValues(t *testing.T) {
+	// the Ruby API sends percentages as strings and unset values as null.
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"key": "search",
+			"state": "conditional",
+			"gates": [
+				{"key": "boolean", "name": "boolean", "value": null},
+				{"key": "actors", "name": "actor", "value": ["User;1"]},
+				{"key": "groups", "name": "group", "value": []},
+				{"key": "percentage_of_actors", "name": "percentage_of_actors", "value": "25"},
+				{"key": "percentage_of_time", "name": "percentage_of_time", "value": null}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	d := newDriver(t, server, nil)
+	g, err := d.Get(feature.NewFeature("search"), []gates.GateKey{
+		gates.BoolGateKey,
+		gates.ActorGateKey,
+		gates.GroupGateKey,
+		gates.PercentageOfActorsGateKey,
+		gates.PercentageOfTimeGateKey,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []gates.Gate{
+		gates.NewActorGate(gates.NewSet("User;1")),
+		gates.NewPercentageOfActorsGate(25),
+	}, g)
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{}, `invalid URL for HTTP's driver: ""`},
+		{map[string]interface{}{"url": "localhost:8080"}, `invalid URL for HTTP's driver: "localhost:8080"`},
+		{map[string]interface{}{"url": "http://localhost", "timeout": "soon"}, "invalid timeout for HTTP's driver"},
+		{map[string]interface{}{"url": "http://localhost", "retries": -1}, "invalid number of retries for HTTP's driver"},
+	}
+
+	for _, test := range tests {
+		err := NewDriver().Configure(test.config)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), test.expected)
+	}
+
+	c := &nethttp.Client{}
+	d := NewDriverWithClient(c)
+	require.NoError(t, d.Configure(map[string]interface{}{"url": "https://example.com/flipper/api"}))
+	require.Equal(t, c, d.client)
+	require.Equal(t, "https://example.com/flipper/api", d.baseURL)
+
+	factory := driver.Lookup("http")
+	require.NotNil(t, factory)
+
+	_, err := factory(map[string]interface{}{"url": "https://example.com/flipper/api", "retries": 2})
+	require.NoError(t, err)
+}
@@ -0,0 +1,334 @@
+// Package memoize provides a store driver that remembers the gates
+// read from another driver for the lifetime of a scope, usually a request,
+// so checking the same feature many times only reads it once.
+//
+// Scopes travel in a context.Context:
+//
+//	d := memoize.NewDriver(redis.NewDriver())
+//	c := client.NewClient(d)
+//	http.Handle("/", d.Middleware("search", "checkout")(handler))
+//
+// Handlers then check features with client.IsEnabledContext(r.Context(), ...).
+package memoize
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// preloadKeys are the gates read when features are preloaded.
+var preloadKeys = []gates.GateKey{
+	gates.BoolGateKey,
+	gates.ActorGateKey,
+	gates.GroupGateKey,
+	gates.PercentageOfActorsGateKey,
+	gates.PercentageOfTimeGateKey,
+	gates.ScheduleGateKey,
+	gates.RampGateKey,
+	gates.ExclusionGateKey,
+}
+
+type config struct {
+	Driver string                 `mapstructure:"driver"`
+	Config map[string]interface{} `mapstructure:"config"`
+}
+
+type scopeKey struct{}
+
+// Scope keeps the gates read during its lifetime.
+// It's safe for concurrent use.
+type Scope struct {
+	mu sync.Mutex
+	// features maps feature names to the gates read for them,
+	// gates that are not set are stored as nil.
+	features map[string]map[gates.GateKey]gates.Gate
+}
+
+// NewScope initializes an empty scope.
+func NewScope() *Scope {
+	return &Scope{features: make(map[string]map[gates.GateKey]gates.Gate)}
+}
+
+// NewContext returns a copy of the context that carries a scope.
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// FromContext returns the scope in a context, if there is one.
+func FromContext(ctx context.Context) (*Scope, bool) {
+	s, ok := ctx.Value(scopeKey{}).(*Scope)
+	return s, ok
+}
+
+// Reset forgets every gate read in the scope.
+func (s *Scope) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.features = make(map[string]map[gates.GateKey]gates.Gate)
+}
+
+// lookup returns the gates for a feature in the order of the keys,
+// or the keys that were not read yet.
+func (s *Scope) lookup(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, []gates.GateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known := s.features[feature.Name]
+
+	var g []gates.Gate
+	var missing []gates.GateKey
+	for _, k := range keys {
+		gate, ok := known[k]
+		if !ok {
+			missing = append(missing, k)
+		} else if gate != nil {
+			g = append(g, gate)
+		}
+	}
+	return g, missing
+}
+
+// store remembers the gates read for a feature,
+// the keys without gates are stored as not set.
+func (s *Scope) store(feature feature.Feature, keys []gates.GateKey, g []gates.Gate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known, ok := s.features[feature.Name]
+	if !ok {
+		known = make(map[gates.GateKey]gates.Gate, len(keys))
+		s.features[feature.Name] = known
+	}
+
+	for _, k := range keys {
+		known[k] = nil
+	}
+	for _, gate := range g {
+		known[gate.Key()] = gate
+	}
+}
+
+func (s *Scope) invalidate(feature feature.Feature) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.features, feature.Name)
+}
+
+// Driver is a store driver that memoizes the gates returned by another driver.
+// Reads are memoized in the scope carried by their context, and in the
+// driver's own scope when it has one. Reads without a scope go to the
+// wrapped driver every time.
+// Enabling, disabling, clearing and removing features through this driver
+// forgets their gates in the scopes involved.
+// Memoized gates are shared between calls and they must not be modified.
+// It's safe for concurrent use.
+type Driver struct {
+	driver driver.Driver
+	scope  *Scope
+}
+
+// NewDriver initializes a driver that memoizes reads
+// in the scopes carried by their context.
+func NewDriver(d driver.Driver) *Driver {
+	return &Driver{driver: d}
+}
+
+// NewDriverWithScope initializes a driver that memoizes every read in a scope,
+// for work that doesn't have a context, like a background job.
+// Create a new driver for each unit of work.
+func NewDriverWithScope(d driver.Driver, s *Scope) *Driver {
+	return &Driver{driver: d, scope: s}
+}
+
+// Configure configures the wrapped driver.
+func (a *Driver) Configure(config map[string]interface{}) error {
+	return a.driver.Configure(config)
+}
+
+// Enable opens a feature for a give gate.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate and forgets its memoized gates.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	defer a.invalidate(ctx, feature)
+	return driver.EnableContext(ctx, a.driver, feature, gate)
+}
+
+// Disable closes a feature for a given gate.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate and forgets its memoized gates.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	defer a.invalidate(ctx, feature)
+	return driver.DisableContext(ctx, a.driver, feature, gate)
+}
+
+// Get returns the gates for a feature, memoized in the driver's scope if it has one.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.GetContext(context.Background(), feature, keys)
+}
+
+// GetContext returns the gates for a feature, memoized in the context's scope
+// or in the driver's scope. Only the gates that were not read before
+// in the scope are read from the wrapped driver.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s := a.scopeFor(ctx)
+	if s == nil {
+		return driver.GetContext(ctx, a.driver, feature, keys)
+	}
+
+	if _, missing := s.lookup(feature, keys); len(missing) > 0 {
+		g, err := driver.GetContext(ctx, a.driver, feature, missing)
+		if err != nil {
+			return nil, err
+		}
+		s.store(feature, missing, g)
+	}
+
+	g, _ := s.lookup(feature, keys)
+	return g, nil
+}
+
+// Preload reads every gate for a list of features into the context's scope
+// or the driver's scope, so later reads don't go to the wrapped driver.
+// Features are read in a single round-trip when the wrapped driver
+// implements the driver.MultiGetter interface.
+// It doesn't do anything when there is no scope.
+func (a *Driver) Preload(ctx context.Context, featureNames ...string) error {
+	s := a.scopeFor(ctx)
+	if s == nil {
+		return ctx.Err()
+	}
+
+	features := make([]feature.Feature, 0, len(featureNames))
+	for _, name := range featureNames {
+		features = append(features, feature.NewFeature(name))
+	}
+
+	g, err := driver.GetMultiContext(ctx, a.driver, features, preloadKeys)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range features {
+		s.store(f, preloadKeys, g[f.Name])
+	}
+	return nil
+}
+
+// Middleware returns an HTTP middleware that gives every request its own scope.
+// The features in preload are read at the beginning of every request,
+// if that fails they are read later when they are checked.
+func (a *Driver) Middleware(preload ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewContext(r.Context(), NewScope())
+			if len(preload) > 0 {
+				a.Preload(ctx, preload...)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Features returns every feature known by the wrapped driver.
+// The list of features is not memoized.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return nil, driver.ErrListingNotSupported
+	}
+	return l.Features()
+}
+
+// Add makes a feature known by the wrapped driver.
+func (a *Driver) Add(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	return l.Add(feature)
+}
+
+// Remove removes a feature from the wrapped driver
+// and forgets its gates in the driver's scope.
+func (a *Driver) Remove(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	defer a.invalidate(context.Background(), feature)
+	return l.Remove(feature)
+}
+
+// Clear removes every gate for a feature from the wrapped driver
+// and forgets its gates in the driver's scope.
+func (a *Driver) Clear(feature feature.Feature) error {
+	l, ok := a.driver.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	defer a.invalidate(context.Background(), feature)
+	return l.Clear(feature)
+}
+
+// scopeFor returns the scope for a read, the context's scope takes precedence.
+func (a *Driver) scopeFor(ctx context.Context) *Scope {
+	if s, ok := FromContext(ctx); ok {
+		return s
+	}
+	return a.scope
+}
+
+func (a *Driver) invalidate(ctx context.Context, feature feature.Feature) {
+	if s, ok := FromContext(ctx); ok {
+		s.invalidate(feature)
+	}
+	if a.scope != nil {
+		a.scope.invalidate(feature)
+	}
+}
+
+// init registers the driver with the name "memoize".
+// These are the options for this driver:
+//   - driver: name of the registered driver to memoize (required)
+//   - config: configuration for the memoized driver (optional)
+//
+// Drivers created by the registry only memoize reads with a scope in their context.
+func init() {
+	driver.Register("memoize", func(c map[string]interface{}) (driver.Driver, error) {
+		var conf config
+		if err := mapstructure.Decode(c, &conf); err != nil {
+			return nil, errors.Wrap(err, "error decoding memoize's driver configuration")
+		}
+
+		factory := driver.Lookup(conf.Driver)
+		if factory == nil {
+			return nil, errors.Errorf("invalid driver to memoize: %q", conf.Driver)
+		}
+
+		d, err := factory(conf.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewDriver(d), nil
+	})
+}
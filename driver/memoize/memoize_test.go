@@ -0,0 +1,197 @@
+package memoize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/client"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDriver is a driver that counts the reads for every feature.
+type countingDriver struct {
+	*memory.Driver
+	reads      map[string]int
+	multiReads int
+	fail       bool
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{Driver: memory.NewDriver(), reads: make(map[string]int)}
+}
+
+func (d *countingDriver) GetContext(ctx context.Context, f feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	if d.fail {
+		return nil, errors.New("driver is down")
+	}
+	d.reads[f.Name]++
+	return d.Driver.GetContext(ctx, f, keys)
+}
+
+func (d *countingDriver) GetMultiContext(ctx context.Context, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	for _, f := range features {
+		d.reads[f.Name]++
+	}
+	d.multiReads++
+	return d.Driver.GetMultiContext(ctx, features, keys)
+}
+
+func TestConformance(t *testing.T) {
+	t.Run("context scope", func(t *testing.T) {
+		drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+			return NewDriver(memory.NewDriver())
+		})
+	})
+
+	t.Run("driver scope", func(t *testing.T) {
+		drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+			return NewDriverWithScope(memory.NewDriver(), NewScope())
+		})
+	})
+}
+
+func TestMemoize(t *testing.T) {
+	search := feature.NewFeature("search")
+	keys := []gates.GateKey{gates.BoolGateKey, gates.ActorGateKey}
+
+	t.Run("without scope", func(t *testing.T) {
+		cd := newCountingDriver()
+		d := NewDriver(cd)
+
+		for i := 0; i < 3; i++ {
+			_, err := d.GetContext(context.Background(), search, keys)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 3, cd.reads["search"])
+	})
+
+	t.Run("context scope", func(t *testing.T) {
+		cd := newCountingDriver()
+		require.NoError(t, cd.Enable(search, gates.NewBoolGate(true)))
+
+		d := NewDriver(cd)
+		ctx := NewContext(context.Background(), NewScope())
+
+		for i := 0; i < 3; i++ {
+			g, err := d.GetContext(ctx, search, keys)
+			require.NoError(t, err)
+			require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+		}
+		require.Equal(t, 1, cd.reads["search"])
+
+		// only the gates that were not read are requested.
+		g, err := d.GetContext(ctx, search, []gates.GateKey{gates.PercentageOfTimeGateKey, gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+		require.Equal(t, 2, cd.reads["search"])
+
+		g, err = d.GetContext(ctx, search, []gates.GateKey{gates.PercentageOfTimeGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+		require.Equal(t, 2, cd.reads["search"])
+
+		// other scopes don't share gates.
+		_, err = d.GetContext(NewContext(context.Background(), NewScope()), search, keys)
+		require.NoError(t, err)
+		require.Equal(t, 3, cd.reads["search"])
+
+		// writes forget the feature's gates.
+		require.NoError(t, d.DisableContext(ctx, search, gates.NewBoolGate(false)))
+		g, err = d.GetContext(ctx, search, keys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+		require.Equal(t, 4, cd.reads["search"])
+	})
+
+	t.Run("driver scope", func(t *testing.T) {
+		cd := newCountingDriver()
+		s := NewScope()
+		d := NewDriverWithScope(cd, s)
+
+		for i := 0; i < 3; i++ {
+			_, err := d.Get(search, keys)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, cd.reads["search"])
+
+		require.NoError(t, d.Enable(search, gates.NewBoolGate(true)))
+		g, err := d.Get(search, keys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+		require.Equal(t, 2, cd.reads["search"])
+
+		s.Reset()
+		_, err = d.Get(search, keys)
+		require.NoError(t, err)
+		require.Equal(t, 3, cd.reads["search"])
+	})
+
+	t.Run("errors are not memoized", func(t *testing.T) {
+		cd := newCountingDriver()
+		cd.fail = true
+
+		d := NewDriver(cd)
+		ctx := NewContext(context.Background(), NewScope())
+
+		_, err := d.GetContext(ctx, search, keys)
+		require.Error(t, err)
+
+		cd.fail = false
+		_, err = d.GetContext(ctx, search, keys)
+		require.NoError(t, err)
+		require.Equal(t, 1, cd.reads["search"])
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	cd := newCountingDriver()
+	require.NoError(t, cd.Enable(feature.NewFeature("search"), gates.NewActorGate(gates.NewSet("User;1"))))
+
+	d := NewDriver(cd)
+	c := client.NewClient(d)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			for _, name := range []string{"search", "checkout"} {
+				_, err := c.IsEnabledContext(r.Context(), name, testhelpers.Actor{ID: "User;1"})
+				require.NoError(t, err)
+			}
+		}
+	})
+
+	server := httptest.NewServer(d.Middleware("search", "signup")(handler))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(server.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	// every feature is read once per request,
+	// search when it's preloaded and checkout the first time it's checked.
+	require.Equal(t, 2, cd.reads["search"])
+	require.Equal(t, 2, cd.reads["checkout"])
+	require.Equal(t, 2, cd.multiReads)
+}
+
+func TestRegister(t *testing.T) {
+	factory := driver.Lookup("memoize")
+	require.NotNil(t, factory)
+
+	d, err := factory(map[string]interface{}{"driver": "memory"})
+	require.NoError(t, err)
+	require.IsType(t, &Driver{}, d)
+
+	_, err = factory(map[string]interface{}{"driver": "unknown"})
+	require.EqualError(t, err, `invalid driver to memoize: "unknown"`)
+}
@@ -1,7 +1,11 @@
 package memory
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/calavera/go-flipper/driver"
 	"github.com/calavera/go-flipper/feature"
@@ -11,15 +15,34 @@ import (
 
 const keyFormat = "feature/%s/%s"
 
+// timeRange is the value stored for gates that use time ranges.
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// ramp is the value stored for gates that use percentages that change over time.
+type ramp struct {
+	start    time.Time
+	duration time.Duration
+	from     int
+	to       int
+}
+
 // Driver is a store driver that keeps features and gates in memory.
+// It's safe for concurrent use.
 type Driver struct {
-	store map[string]interface{}
+	mu       sync.RWMutex
+	store    map[string]interface{}
+	features gates.Set
 }
 
 // NewDriver initializes a new memory driver.
 func NewDriver() *Driver {
-	store := make(map[string]interface{})
-	return &Driver{store}
+	return &Driver{
+		store:    make(map[string]interface{}),
+		features: gates.Set{},
+	}
 }
 
 // Configure configures the memory driver.
@@ -29,12 +52,19 @@ func (a *Driver) Configure(config map[string]interface{}) error {
 }
 
 // Enable opens a feature for a give gate.
+// Enabling the boolean gate clears any other gate,
+// the same way the Ruby adapters do.
 func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.features[feature.Name] = feature.Name
 	k := key(feature.Name, gate.Key())
 
 	if g, ok := gate.(gates.IntGateType); ok {
-		a.store[k] = g.IntValue()
+		a.setNumber(k, gates.NumberValue(g))
 	} else if _, ok := gate.(gates.BoolGateType); ok {
+		a.clear(feature)
 		a.store[k] = true
 	} else if g, ok := gate.(gates.SetGateType); ok {
 		var gs gates.Set
@@ -52,6 +82,12 @@ func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
 		}
 
 		a.store[k] = gs
+	} else if g, ok := gate.(gates.TimeRangeGateType); ok {
+		start, end := g.TimeRange()
+		a.store[k] = timeRange{start: start, end: end}
+	} else if g, ok := gate.(gates.RampGateType); ok {
+		start, duration, from, to := g.Ramp()
+		a.store[k] = ramp{start: start, duration: duration, from: from, to: to}
 	} else {
 		return errors.Errorf("unsupported data type: %v", gate.Key())
 	}
@@ -60,24 +96,37 @@ func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
 }
 
 // Disable closes a feature for a given gate.
+// Disabling the boolean gate removes every gate for the feature,
+// the same way the Ruby adapters do.
 func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	k := key(feature.Name, gate.Key())
 
 	if g, ok := gate.(gates.IntGateType); ok {
-		a.store[k] = g.IntValue()
+		a.setNumber(k, gates.NumberValue(g))
 	} else if _, ok := gate.(gates.BoolGateType); ok {
-		delete(a.store, k)
+		a.clear(feature)
 	} else if g, ok := gate.(gates.SetGateType); ok {
 		if s, ok := a.store[k]; ok {
 			gs, ok := s.(gates.Set)
 			if !ok {
 				return errors.Errorf("unexpected set value disabling feature: %v", s)
 			}
-			for k := range g.SetValue() {
-				delete(gs, k)
+			for v := range g.SetValue() {
+				delete(gs, v)
+			}
+			if len(gs) == 0 {
+				delete(a.store, k)
+			} else {
+				a.store[k] = gs
 			}
-			a.store[k] = gs
 		}
+	} else if _, ok := gate.(gates.TimeRangeGateType); ok {
+		delete(a.store, k)
+	} else if _, ok := gate.(gates.RampGateType); ok {
+		delete(a.store, k)
 	} else {
 		return errors.Errorf("unsupported data type: %v", gate.Key())
 	}
@@ -86,8 +135,38 @@ func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
 }
 
 // Get returns the enabled gates for a feature given a set of gate keys.
-// Gates are skipped if they are not open for a feature.
+// Gates are skipped if they are not set for a feature.
+// Set gates are copies of the stored values, they can be modified safely.
 func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.get(feature, keys)
+}
+
+// GetMulti returns the enabled gates for many features given a set of gate keys,
+// indexed by feature name. Features without gates are not in the result.
+// This satisfies the driver.MultiGetter interface.
+func (a *Driver) GetMulti(features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make(map[string][]gates.Gate, len(features))
+	for _, f := range features {
+		g, err := a.get(f, keys)
+		if err != nil {
+			return nil, err
+		}
+		if len(g) > 0 {
+			result[f.Name] = g
+		}
+	}
+	return result, nil
+}
+
+// get returns the enabled gates for a feature.
+// The caller must hold the read lock.
+func (a *Driver) get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
 	var g []gates.Gate
 
 	for _, t := range keys {
@@ -99,31 +178,53 @@ func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gat
 
 		switch t {
 		case gates.BoolGateKey:
-			g = append(g, gates.NewBoolGate(ok))
+			gb, ok := v.(bool)
+			if !ok {
+				return nil, errors.Errorf("unexpected bool value stored: %v", v)
+			}
+			g = append(g, gates.NewBoolGate(gb))
 		case gates.ActorGateKey:
 			gs, ok := v.(gates.Set)
 			if !ok {
 				return nil, errors.Errorf("unexpected set value stored: %v", v)
 			}
-			g = append(g, gates.NewActorGate(gs))
+			g = append(g, gates.NewActorGate(copySet(gs)))
 		case gates.GroupGateKey:
 			gs, ok := v.(gates.Set)
 			if !ok {
 				return nil, errors.Errorf("unexpected set value stored: %v", v)
 			}
-			g = append(g, gates.NewGroupGate(gs))
+			g = append(g, gates.NewGroupGate(copySet(gs)))
+		case gates.ExclusionGateKey:
+			gs, ok := v.(gates.Set)
+			if !ok {
+				return nil, errors.Errorf("unexpected set value stored: %v", v)
+			}
+			g = append(g, gates.NewExclusionGate(copySet(gs)))
 		case gates.PercentageOfActorsGateKey:
-			gi, ok := v.(int)
+			gf, ok := v.(float64)
 			if !ok {
-				return nil, errors.Errorf("unexpected int value: %v", v)
+				return nil, errors.Errorf("unexpected number value: %v", v)
 			}
-			g = append(g, gates.NewPercentageOfActorsGate(gi))
+			g = append(g, gates.NewFractionalPercentageOfActorsGate(gf))
 		case gates.PercentageOfTimeGateKey:
-			gi, ok := v.(int)
+			gf, ok := v.(float64)
+			if !ok {
+				return nil, errors.Errorf("unexpected number value: %v", v)
+			}
+			g = append(g, gates.NewFractionalPercentageOfTimeGate(gf))
+		case gates.ScheduleGateKey:
+			tr, ok := v.(timeRange)
+			if !ok {
+				return nil, errors.Errorf("unexpected time range value stored: %v", v)
+			}
+			g = append(g, gates.NewScheduleGate(tr.start, tr.end))
+		case gates.RampGateKey:
+			r, ok := v.(ramp)
 			if !ok {
-				return nil, errors.Errorf("unexpected int value: %v", v)
+				return nil, errors.Errorf("unexpected ramp value stored: %v", v)
 			}
-			g = append(g, gates.NewPercentageOfTimeGate(gi))
+			g = append(g, gates.NewRampGate(r.start, r.duration, r.from, r.to))
 		default:
 			return nil, errors.Errorf("unsupported gate: %v", t)
 		}
@@ -132,10 +233,118 @@ func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gat
 	return g, nil
 }
 
+// EnableContext opens a feature for a give gate if the context is not done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Enable(feature, gate)
+}
+
+// DisableContext closes a feature for a given gate if the context is not done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Disable(feature, gate)
+}
+
+// GetContext returns the enabled gates for a feature if the context is not done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Get(feature, keys)
+}
+
+// GetMultiContext returns the enabled gates for many features if the context is not done.
+// This satisfies the driver.MultiGetter interface.
+func (a *Driver) GetMultiContext(ctx context.Context, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.GetMulti(features, keys)
+}
+
+// Features returns every feature known by the driver.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	f := make([]feature.Feature, 0, len(a.features))
+	for name := range a.features {
+		f = append(f, feature.NewFeature(name))
+	}
+	return f, nil
+}
+
+// Add makes a feature known by the driver without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.features[feature.Name] = feature.Name
+	return nil
+}
+
+// Remove clears every gate for a feature and forgets about it.
+func (a *Driver) Remove(feature feature.Feature) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.features, feature.Name)
+	a.clear(feature)
+	return nil
+}
+
+// Clear removes every gate value for a feature.
+func (a *Driver) Clear(feature feature.Feature) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clear(feature)
+	return nil
+}
+
+func (a *Driver) clear(feature feature.Feature) {
+	prefix := key(feature.Name, "")
+	for k := range a.store {
+		// gate keys never include slashes, this prevents
+		// clearing features that share the same name prefix.
+		if strings.HasPrefix(k, prefix) && !strings.Contains(k[len(prefix):], "/") {
+			delete(a.store, k)
+		}
+	}
+}
+
+// setNumber stores a numeric value for a gate.
+// Zero values are not stored, a gate with a zero percentage is never open.
+func (a *Driver) setNumber(k string, v float64) {
+	if v == 0 {
+		delete(a.store, k)
+	} else {
+		a.store[k] = v
+	}
+}
+
+func copySet(s gates.Set) gates.Set {
+	c := make(gates.Set, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
+
 func key(featureName string, gateKey gates.GateKey) string {
 	return fmt.Sprintf(keyFormat, featureName, gateKey)
 }
 
 func init() {
-	driver.Init("memory", NewDriver())
+	driver.Register("memory", func(config map[string]interface{}) (driver.Driver, error) {
+		d := NewDriver()
+		if err := d.Configure(config); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
 }
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		return NewDriver()
+	})
+}
+
+func TestGateConformance(t *testing.T) {
+	newDriver := func(t *testing.T) driver.Driver {
+		return NewDriver()
+	}
+
+	t.Run("schedule gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewScheduleGate(start, start.Add(time.Hour)),
+			gates.NewScheduleGate(time.Time{}, time.Time{}))
+	})
+
+	t.Run("ramp gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewRampGate(start, 72*time.Hour, 1, 100),
+			gates.NewRampGate(time.Time{}, 0, 0, 0))
+	})
+
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
+}
+
+func TestGetReturnsCopies(t *testing.T) {
+	d := NewDriver()
+	feat := feature.NewFeature("test")
+
+	set := gates.NewSet("User;1")
+	require.NoError(t, d.Enable(feat, gates.NewActorGate(set)))
+	set["User;2"] = "User;2"
+
+	g, err := d.Get(feat, []gates.GateKey{gates.ActorGateKey})
+	require.NoError(t, err)
+	require.Len(t, g, 1)
+
+	stored := g[0].(gates.ActorGate).SetValue()
+	require.Equal(t, gates.NewSet("User;1"), stored)
+	stored["User;3"] = "User;3"
+
+	g, err = d.Get(feat, []gates.GateKey{gates.ActorGateKey})
+	require.NoError(t, err)
+	require.Equal(t, gates.NewSet("User;1"), g[0].(gates.ActorGate).SetValue())
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	d := NewDriver()
+	keys := []gates.GateKey{
+		gates.BoolGateKey,
+		gates.ActorGateKey,
+		gates.GroupGateKey,
+		gates.PercentageOfActorsGateKey,
+		gates.PercentageOfTimeGateKey,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			feat := feature.NewFeature(fmt.Sprintf("feature-%d", i%5))
+			actor := fmt.Sprintf("User;%d", i)
+
+			for j := 0; j < 100; j++ {
+				require.NoError(t, d.Enable(feat, gates.NewActorGate(gates.NewSet(actor))))
+				require.NoError(t, d.Enable(feat, gates.NewBoolGate(true)))
+				require.NoError(t, d.Enable(feat, gates.NewPercentageOfActorsGate(j)))
+
+				_, err := d.Get(feat, keys)
+				require.NoError(t, err)
+
+				require.NoError(t, d.Disable(feat, gates.NewActorGate(gates.NewSet(actor))))
+				require.NoError(t, d.Disable(feat, gates.NewBoolGate(false)))
+
+				_, err = d.Features()
+				require.NoError(t, err)
+
+				if j%10 == 0 {
+					require.NoError(t, d.Clear(feat))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
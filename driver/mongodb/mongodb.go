@@ -1,6 +1,9 @@
 package mongodb
 
 import (
+	"context"
+	"time"
+
 	"github.com/calavera/go-flipper/driver"
 	"github.com/calavera/go-flipper/feature"
 	"github.com/calavera/go-flipper/gates"
@@ -10,7 +13,13 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
-const defaultCollectionName = "flipper"
+const (
+	defaultCollectionName = "flipper"
+
+	// FeaturesKey is the id of the document where flipper keeps the name of every known feature.
+	// It's the same document that the Ruby flipper-mongo adapter uses.
+	FeaturesKey = "flipper_features"
+)
 
 type config struct {
 	URL        string `mapstructure:"url"`
@@ -18,12 +27,36 @@ type config struct {
 	Collection string `mapstructure:"collection"`
 }
 
+type featuresDoc struct {
+	Features []string `bson:"features"`
+}
+
 type featureDoc struct {
-	Actors             []string `bson:"actors"`
-	Groups             []string `bson:"groups"`
-	Boolean            bool     `bson:"boolean"`
-	PercentageOfActors int      `bson:"percentage_of_actors"`
-	PercentageOfTime   int      `bson:"percentage_of_time"`
+	Name               string        `bson:"_id"`
+	Actors             []string      `bson:"actors"`
+	Groups             []string      `bson:"groups"`
+	Boolean            bool          `bson:"boolean"`
+	PercentageOfActors float64       `bson:"percentage_of_actors"`
+	PercentageOfTime   float64       `bson:"percentage_of_time"`
+	Schedule           *timeRangeDoc `bson:"schedule,omitempty"`
+	Ramp               *rampDoc      `bson:"ramp,omitempty"`
+	ExcludedActors     []string      `bson:"excluded_actors"`
+}
+
+// timeRangeDoc stores gates that use time ranges.
+// MongoDB keeps times with millisecond precision.
+type timeRangeDoc struct {
+	Start time.Time `bson:"start,omitempty"`
+	End   time.Time `bson:"end,omitempty"`
+}
+
+// rampDoc stores gates that use percentages that change over time.
+// Durations are kept in nanoseconds.
+type rampDoc struct {
+	Start    time.Time     `bson:"start"`
+	Duration time.Duration `bson:"duration"`
+	From     int           `bson:"from"`
+	To       int           `bson:"to"`
 }
 
 // Driver is a store driver that keeps features and gates in mongoDB.
@@ -77,81 +110,194 @@ func (a *Driver) Configure(c map[string]interface{}) error {
 }
 
 // Enable opens a feature for a give gate.
+// Enabling the boolean gate clears any other gate,
+// the same way the Ruby adapters do.
 func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
-	var err error
-	key := string(gate.Key())
-
-	if g, ok := gate.(gates.IntGateType); ok {
-		set := bson.M{"$set": bson.M{key: g.IntValue()}}
-		_, err = a.collection.UpsertId(feature.Name, set)
-	} else if _, ok := gate.(gates.BoolGateType); ok {
-		set := bson.M{"$set": bson.M{key: true}}
-		_, err = a.collection.UpsertId(feature.Name, set)
-	} else if g, ok := gate.(gates.SetGateType); ok {
-		set := make([]string, 0, len(g.SetValue()))
-		for k := range g.SetValue() {
-			set = append(set, k)
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate.
+// It stops waiting for mongoDB when the context is done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.run(ctx, func(c *mgo.Collection) error {
+		up := bson.M{"$addToSet": bson.M{"features": feature.Name}}
+		if _, err := c.UpsertId(FeaturesKey, up); err != nil {
+			return err
 		}
-		up := bson.M{"$addToSet": bson.M{key: bson.M{"$each": set}}}
-		_, err = a.collection.UpsertId(feature.Name, up)
-	} else {
-		err = errors.Errorf("unsupported data type: %v", gate.Key())
-	}
 
-	return err
+		var err error
+		key := string(gate.Key())
+
+		if g, ok := gate.(gates.IntGateType); ok {
+			set := bson.M{"$set": bson.M{key: gates.NumberValue(g)}}
+			_, err = c.UpsertId(feature.Name, set)
+		} else if _, ok := gate.(gates.BoolGateType); ok {
+			// a document without update operators replaces every gate.
+			_, err = c.UpsertId(feature.Name, bson.M{key: true})
+		} else if g, ok := gate.(gates.SetGateType); ok {
+			set := make([]string, 0, len(g.SetValue()))
+			for k := range g.SetValue() {
+				set = append(set, k)
+			}
+			up := bson.M{"$addToSet": bson.M{key: bson.M{"$each": set}}}
+			_, err = c.UpsertId(feature.Name, up)
+		} else if g, ok := gate.(gates.TimeRangeGateType); ok {
+			start, end := g.TimeRange()
+			set := bson.M{"$set": bson.M{key: timeRangeDoc{Start: start, End: end}}}
+			_, err = c.UpsertId(feature.Name, set)
+		} else if g, ok := gate.(gates.RampGateType); ok {
+			start, duration, from, to := g.Ramp()
+			set := bson.M{"$set": bson.M{key: rampDoc{Start: start, Duration: duration, From: from, To: to}}}
+			_, err = c.UpsertId(feature.Name, set)
+		} else {
+			err = errors.Errorf("unsupported data type: %v", gate.Key())
+		}
+
+		return err
+	})
 }
 
 // Disable closes a feature for a given gate.
+// Disabling the boolean gate removes every gate for the feature,
+// the same way the Ruby adapters do.
 func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
-	var err error
-	key := string(gate.Key())
-
-	if g, ok := gate.(gates.IntGateType); ok {
-		set := bson.M{"$set": bson.M{key: g.IntValue()}}
-		_, err = a.collection.UpsertId(feature.Name, set)
-	} else if _, ok := gate.(gates.BoolGateType); ok {
-		err = a.collection.RemoveId(feature.Name)
-	} else if g, ok := gate.(gates.SetGateType); ok {
-		set := make([]string, 0, len(g.SetValue()))
-		for k := range g.SetValue() {
-			set = append(set, k)
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate.
+// It stops waiting for mongoDB when the context is done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.run(ctx, func(c *mgo.Collection) error {
+		var err error
+		key := string(gate.Key())
+
+		if g, ok := gate.(gates.IntGateType); ok {
+			set := bson.M{"$set": bson.M{key: gates.NumberValue(g)}}
+			_, err = c.UpsertId(feature.Name, set)
+		} else if _, ok := gate.(gates.BoolGateType); ok {
+			if err = c.RemoveId(feature.Name); err == mgo.ErrNotFound {
+				err = nil
+			}
+		} else if g, ok := gate.(gates.SetGateType); ok {
+			set := make([]string, 0, len(g.SetValue()))
+			for k := range g.SetValue() {
+				set = append(set, k)
+			}
+			up := bson.M{"$pull": bson.M{key: bson.M{"$in": set}}}
+			_, err = c.UpsertId(feature.Name, up)
+		} else if _, ok := gate.(gates.TimeRangeGateType); ok {
+			unset := bson.M{"$unset": bson.M{key: ""}}
+			_, err = c.UpsertId(feature.Name, unset)
+		} else if _, ok := gate.(gates.RampGateType); ok {
+			unset := bson.M{"$unset": bson.M{key: ""}}
+			_, err = c.UpsertId(feature.Name, unset)
+		} else {
+			err = errors.Errorf("unsupported data type: %v", gate.Key())
 		}
-		up := bson.M{"$pull": bson.M{key: bson.M{"$in": set}}}
-		_, err = a.collection.UpsertId(feature.Name, up)
-	} else {
-		err = errors.Errorf("unsupported data type: %v", gate.Key())
-	}
 
-	return err
+		return err
+	})
 }
 
 // Get returns the enabled gates for a feature given a set of gate keys.
-// Gates are skipped if they are not open for a feature.
+// Gates are skipped if they are not set for a feature.
 func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
-	var g []gates.Gate
+	return a.GetContext(context.Background(), feature, keys)
+}
 
+// GetContext returns the enabled gates for a feature given a set of gate keys.
+// It stops waiting for mongoDB when the context is done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
 	var result featureDoc
-	if err := a.collection.FindId(feature.Name).One(&result); err != nil {
+	err := a.run(ctx, func(c *mgo.Collection) error {
+		return c.FindId(feature.Name).One(&result)
+	})
+	if err != nil {
 		if err == mgo.ErrNotFound {
-			return g, nil
+			return nil, nil
 		}
 		return nil, err
 	}
 
+	return result.enabledGates(keys)
+}
+
+// GetMulti returns the enabled gates for many features given a set of gate keys,
+// indexed by feature name, with a single query.
+// Features without gates are not in the result.
+// This satisfies the driver.MultiGetter interface.
+func (a *Driver) GetMulti(features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	return a.GetMultiContext(context.Background(), features, keys)
+}
+
+// GetMultiContext returns the enabled gates for many features given a set of gate keys.
+// It stops waiting for mongoDB when the context is done.
+// This satisfies the driver.MultiGetter interface.
+func (a *Driver) GetMultiContext(ctx context.Context, features []feature.Feature, keys []gates.GateKey) (map[string][]gates.Gate, error) {
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, f.Name)
+	}
+
+	var docs []featureDoc
+	err := a.run(ctx, func(c *mgo.Collection) error {
+		return c.Find(bson.M{"_id": bson.M{"$in": names}}).All(&docs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]gates.Gate, len(docs))
+	for _, d := range docs {
+		g, err := d.enabledGates(keys)
+		if err != nil {
+			return nil, err
+		}
+		if len(g) > 0 {
+			result[d.Name] = g
+		}
+	}
+	return result, nil
+}
+
+// enabledGates returns the enabled gates in a feature document given a set of gate keys.
+func (result featureDoc) enabledGates(keys []gates.GateKey) ([]gates.Gate, error) {
+	var g []gates.Gate
+
 	for _, t := range keys {
 		switch t {
 		case gates.BoolGateKey:
-			g = append(g, gates.NewBoolGate(result.Boolean))
+			if result.Boolean {
+				g = append(g, gates.NewBoolGate(result.Boolean))
+			}
 		case gates.ActorGateKey:
-			set := gates.NewSet(result.Actors...)
-			g = append(g, gates.NewActorGate(set))
+			if len(result.Actors) > 0 {
+				g = append(g, gates.NewActorGate(gates.NewSet(result.Actors...)))
+			}
 		case gates.GroupGateKey:
-			set := gates.NewSet(result.Groups...)
-			g = append(g, gates.NewGroupGate(set))
+			if len(result.Groups) > 0 {
+				g = append(g, gates.NewGroupGate(gates.NewSet(result.Groups...)))
+			}
+		case gates.ExclusionGateKey:
+			if len(result.ExcludedActors) > 0 {
+				g = append(g, gates.NewExclusionGate(gates.NewSet(result.ExcludedActors...)))
+			}
 		case gates.PercentageOfActorsGateKey:
-			g = append(g, gates.NewPercentageOfActorsGate(result.PercentageOfActors))
+			if result.PercentageOfActors > 0 {
+				g = append(g, gates.NewFractionalPercentageOfActorsGate(result.PercentageOfActors))
+			}
 		case gates.PercentageOfTimeGateKey:
-			g = append(g, gates.NewPercentageOfTimeGate(result.PercentageOfTime))
+			if result.PercentageOfTime > 0 {
+				g = append(g, gates.NewFractionalPercentageOfTimeGate(result.PercentageOfTime))
+			}
+		case gates.ScheduleGateKey:
+			if result.Schedule != nil {
+				g = append(g, gates.NewScheduleGate(result.Schedule.Start, result.Schedule.End))
+			}
+		case gates.RampGateKey:
+			if r := result.Ramp; r != nil {
+				g = append(g, gates.NewRampGate(r.Start, r.Duration, r.From, r.To))
+			}
 		default:
 			return nil, errors.Errorf("unsupported gate: %v", t)
 		}
@@ -160,6 +306,82 @@ func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gat
 	return g, nil
 }
 
+// Features returns every feature known by the driver.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	var result featuresDoc
+	if err := a.collection.FindId(FeaturesKey).One(&result); err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+
+	f := make([]feature.Feature, 0, len(result.Features))
+	for _, name := range result.Features {
+		f = append(f, feature.NewFeature(name))
+	}
+	return f, nil
+}
+
+// Add makes a feature known by the driver without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	up := bson.M{"$addToSet": bson.M{"features": feature.Name}}
+	_, err := a.collection.UpsertId(FeaturesKey, up)
+	return err
+}
+
+// Remove clears every gate for a feature and forgets about it.
+func (a *Driver) Remove(feature feature.Feature) error {
+	up := bson.M{"$pull": bson.M{"features": feature.Name}}
+	if _, err := a.collection.UpsertId(FeaturesKey, up); err != nil {
+		return err
+	}
+	return a.Clear(feature)
+}
+
+// Clear removes every gate value for a feature.
+func (a *Driver) Clear(feature feature.Feature) error {
+	if err := a.collection.RemoveId(feature.Name); err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// run executes a function with the driver's collection honoring the context.
+// When the context can be cancelled, the function runs with a copy of the session,
+// which uses the context's deadline as socket timeout, and run returns
+// as soon as the context is done, without waiting for mongoDB to reply.
+func (a *Driver) run(ctx context.Context, f func(*mgo.Collection) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		return f(a.collection)
+	}
+
+	session := a.collection.Database.Session.Copy()
+	if deadline, ok := ctx.Deadline(); ok {
+		session.SetSocketTimeout(time.Until(deadline))
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		defer session.Close()
+		errc <- f(a.collection.With(session))
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func init() {
-	driver.Init("mongodb", NewDriver())
+	driver.Register("mongodb", func(config map[string]interface{}) (driver.Driver, error) {
+		d := NewDriver()
+		if err := d.Configure(config); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
 }
@@ -1,12 +1,16 @@
 package mongodb
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	mgo "gopkg.in/mgo.v2"
 
 	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
 	"github.com/calavera/go-flipper/feature"
 	"github.com/calavera/go-flipper/gates"
 	"github.com/stretchr/testify/require"
@@ -138,4 +142,104 @@ func TestMongoDB(t *testing.T) {
 
 		require.Equal(t, 30, b.IntValue())
 	})
+
+	t.Run("list features", func(t *testing.T) {
+		db.DropDatabase()
+
+		err := driver.Add(feature.NewFeature("search"))
+		require.NoError(t, err)
+		err = driver.Enable(feature.NewFeature("checkout"), gates.NewBoolGate(true))
+		require.NoError(t, err)
+
+		features, err := driver.Features()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []feature.Feature{feature.NewFeature("search"), feature.NewFeature("checkout")}, features)
+
+		err = driver.Clear(feature.NewFeature("checkout"))
+		require.NoError(t, err)
+
+		g, err := driver.Get(feature.NewFeature("checkout"), []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		err = driver.Remove(feature.NewFeature("search"))
+		require.NoError(t, err)
+
+		features, err = driver.Features()
+		require.NoError(t, err)
+		require.Equal(t, []feature.Feature{feature.NewFeature("checkout")}, features)
+	})
+
+	db.DropDatabase()
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		feat := feature.NewFeature("test")
+		_, err := driver.GetContext(ctx, feat, []gates.GateKey{gates.BoolGateKey})
+		require.Error(t, err)
+
+		err = driver.EnableContext(ctx, feat, gates.NewBoolGate(true))
+		require.Error(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	url := os.Getenv(testConnectionURL)
+	if url == "" {
+		t.SkipNow()
+	}
+
+	session, err := mgo.Dial(url)
+	require.NoError(t, err)
+	defer session.Close()
+
+	db := session.DB("")
+
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		db.DropDatabase()
+		return NewDriverWithCollection(db.C(defaultCollectionName))
+	})
+}
+
+func TestGateConformance(t *testing.T) {
+	url := os.Getenv(testConnectionURL)
+	if url == "" {
+		t.SkipNow()
+	}
+
+	session, err := mgo.Dial(url)
+	require.NoError(t, err)
+	defer session.Close()
+
+	db := session.DB("")
+	newDriver := func(t *testing.T) driver.Driver {
+		db.DropDatabase()
+		return NewDriverWithCollection(db.C(defaultCollectionName))
+	}
+
+	t.Run("schedule gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewScheduleGate(start, start.Add(time.Hour)),
+			gates.NewScheduleGate(time.Time{}, time.Time{}))
+	})
+
+	t.Run("ramp gate", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewRampGate(start, 72*time.Hour, 1, 100),
+			gates.NewRampGate(time.Time{}, 0, 0, 0))
+	})
+
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
 }
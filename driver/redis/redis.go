@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// FeaturesKey is the set where flipper-redis keeps the name of every known feature.
+const FeaturesKey = "flipper_features"
+
+const (
+	defaultMaxIdle = 3
+	fieldSeparator = "/"
+	trueValue      = "true"
+)
+
+type config struct {
+	URL     string `mapstructure:"url"`
+	MaxIdle int    `mapstructure:"max_idle"`
+}
+
+// Driver is a store driver that keeps features and gates in Redis.
+// It uses the same layout as the Ruby flipper-redis adapter,
+// one hash per feature and a set with all the feature names:
+//   - boolean and percentage gates are stored in the fields "boolean",
+//     "percentage_of_actors" and "percentage_of_time".
+//   - set gates are stored as one field per value, "actors/<id>" and "groups/<name>".
+type Driver struct {
+	pool *redigo.Pool
+}
+
+// NewDriver initializes a new Redis driver.
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+// NewDriverWithPool initializes a new Redis driver with a given connection pool.
+// This factory allows you to reuse a pool open in your program.
+func NewDriverWithPool(p *redigo.Pool) *Driver {
+	return &Driver{p}
+}
+
+// Configure configures the Redis driver.
+// These are the options for this driver:
+//   - url: string url to the Redis server, like redis://127.0.0.1:6379/0 (required)
+//   - max_idle: maximum number of idle connections in the pool (optional - default 3)
+// This function doesn't do anything if the driver already has a pool configured.
+func (a *Driver) Configure(c map[string]interface{}) error {
+	if a.pool != nil {
+		return nil
+	}
+
+	var conf config
+	if err := mapstructure.Decode(c, &conf); err != nil {
+		return errors.Wrap(err, "error decoding Redis's driver configuration")
+	}
+
+	if conf.URL == "" {
+		return errors.New("invalid connection URL for Redis's driver")
+	}
+
+	if conf.MaxIdle == 0 {
+		conf.MaxIdle = defaultMaxIdle
+	}
+
+	conn, err := redigo.DialURL(conf.URL)
+	if err != nil {
+		return errors.Wrap(err, "error connecting to Redis")
+	}
+	conn.Close()
+
+	a.pool = &redigo.Pool{
+		MaxIdle: conf.MaxIdle,
+		Dial: func() (redigo.Conn, error) {
+			return redigo.DialURL(conf.URL)
+		},
+	}
+
+	return nil
+}
+
+// Enable opens a feature for a give gate.
+// Enabling the boolean gate clears any other gate,
+// the same way the Ruby adapter does.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate.
+// It stops waiting for Redis when the context is done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	conn, err := a.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	key := string(gate.Key())
+
+	conn.Send("MULTI")
+	conn.Send("SADD", FeaturesKey, feature.Name)
+
+	if g, ok := gate.(gates.IntGateType); ok {
+		cmd, args := numberCommand(feature, key, gates.NumberValue(g))
+		conn.Send(cmd, args...)
+	} else if _, ok := gate.(gates.BoolGateType); ok {
+		conn.Send("DEL", feature.Name)
+		conn.Send("HSET", feature.Name, key, trueValue)
+	} else if g, ok := gate.(gates.SetGateType); ok {
+		args := redigo.Args{}.Add(feature.Name)
+		for v := range g.SetValue() {
+			args = args.Add(field(gate.Key(), v), 1)
+		}
+		conn.Send("HMSET", args...)
+	} else {
+		conn.Do("DISCARD")
+		return errors.Errorf("unsupported data type: %v", gate.Key())
+	}
+
+	_, err = redigo.DoContext(conn, ctx, "EXEC")
+	return err
+}
+
+// Disable closes a feature for a given gate.
+// Disabling the boolean gate removes every gate for the feature,
+// the same way the Ruby adapter does.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate.
+// It stops waiting for Redis when the context is done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	conn, err := a.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	key := string(gate.Key())
+
+	if g, ok := gate.(gates.IntGateType); ok {
+		cmd, args := numberCommand(feature, key, gates.NumberValue(g))
+		_, err = redigo.DoContext(conn, ctx, cmd, args...)
+	} else if _, ok := gate.(gates.BoolGateType); ok {
+		_, err = redigo.DoContext(conn, ctx, "DEL", feature.Name)
+	} else if g, ok := gate.(gates.SetGateType); ok {
+		args := redigo.Args{}.Add(feature.Name)
+		for v := range g.SetValue() {
+			args = args.Add(field(gate.Key(), v))
+		}
+		_, err = redigo.DoContext(conn, ctx, "HDEL", args...)
+	} else {
+		err = errors.Errorf("unsupported data type: %v", gate.Key())
+	}
+
+	return err
+}
+
+// Get returns the enabled gates for a feature given a set of gate keys.
+// Gates are skipped if they are not set for a feature.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.GetContext(context.Background(), feature, keys)
+}
+
+// GetContext returns the enabled gates for a feature given a set of gate keys.
+// It stops waiting for Redis when the context is done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	conn, err := a.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	doc, err := redigo.StringMap(redigo.DoContext(conn, ctx, "HGETALL", feature.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	var g []gates.Gate
+
+	for _, t := range keys {
+		switch t {
+		case gates.BoolGateKey:
+			if doc[string(t)] == trueValue {
+				g = append(g, gates.NewBoolGate(true))
+			}
+		case gates.ActorGateKey:
+			if set := setValue(doc, t); len(set) > 0 {
+				g = append(g, gates.NewActorGate(set))
+			}
+		case gates.GroupGateKey:
+			if set := setValue(doc, t); len(set) > 0 {
+				g = append(g, gates.NewGroupGate(set))
+			}
+		case gates.ExclusionGateKey:
+			if set := setValue(doc, t); len(set) > 0 {
+				g = append(g, gates.NewExclusionGate(set))
+			}
+		case gates.PercentageOfActorsGateKey:
+			if v, ok := doc[string(t)]; ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, errors.Errorf("unexpected number value stored: %v", v)
+				}
+				if f > 0 {
+					g = append(g, gates.NewFractionalPercentageOfActorsGate(f))
+				}
+			}
+		case gates.PercentageOfTimeGateKey:
+			if v, ok := doc[string(t)]; ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, errors.Errorf("unexpected number value stored: %v", v)
+				}
+				if f > 0 {
+					g = append(g, gates.NewFractionalPercentageOfTimeGate(f))
+				}
+			}
+		default:
+			// gates that this driver can't store are never set.
+		}
+	}
+
+	return g, nil
+}
+
+// Features returns every feature known by the driver.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	conn := a.pool.Get()
+	defer conn.Close()
+
+	names, err := redigo.Strings(conn.Do("SMEMBERS", FeaturesKey))
+	if err != nil {
+		return nil, err
+	}
+
+	f := make([]feature.Feature, 0, len(names))
+	for _, name := range names {
+		f = append(f, feature.NewFeature(name))
+	}
+	return f, nil
+}
+
+// Add makes a feature known by the driver without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	conn := a.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", FeaturesKey, feature.Name)
+	return err
+}
+
+// Remove clears every gate for a feature and forgets about it.
+func (a *Driver) Remove(feature feature.Feature) error {
+	conn := a.pool.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("SREM", FeaturesKey, feature.Name)
+	conn.Send("DEL", feature.Name)
+	_, err := conn.Do("EXEC")
+	return err
+}
+
+// Clear removes every gate value for a feature.
+func (a *Driver) Clear(feature feature.Feature) error {
+	conn := a.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", feature.Name)
+	return err
+}
+
+// numberCommand returns the command to store a numeric value for a gate.
+// Zero values are not stored, a gate with a zero percentage is never open.
+func numberCommand(feature feature.Feature, key string, v float64) (string, []interface{}) {
+	if v == 0 {
+		return "HDEL", []interface{}{feature.Name, key}
+	}
+	return "HSET", []interface{}{feature.Name, key, strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+func field(gateKey gates.GateKey, value string) string {
+	return string(gateKey) + fieldSeparator + value
+}
+
+func setValue(doc map[string]string, gateKey gates.GateKey) gates.Set {
+	prefix := string(gateKey) + fieldSeparator
+	set := gates.Set{}
+	for f := range doc {
+		if strings.HasPrefix(f, prefix) {
+			v := strings.TrimPrefix(f, prefix)
+			set[v] = v
+		}
+	}
+	return set
+}
+
+func init() {
+	driver.Register("redis", func(config map[string]interface{}) (driver.Driver, error) {
+		d := NewDriver()
+		if err := d.Configure(config); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
+}
@@ -0,0 +1,274 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	url := "redis://" + s.Addr()
+
+	t.Run("configure", func(t *testing.T) {
+		d := NewDriver()
+		err := d.Configure(map[string]interface{}{
+			"url": url,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("configure without url", func(t *testing.T) {
+		d := NewDriver()
+		err := d.Configure(nil)
+		require.Error(t, err)
+	})
+
+	driver := NewDriver()
+	require.NoError(t, driver.Configure(map[string]interface{}{"url": url}))
+
+	t.Run("enable for system", func(t *testing.T) {
+		gate := gates.NewBoolGate(true)
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+
+		require.Equal(t, "true", s.HGet("test", "boolean"))
+		require.True(t, s.Exists(FeaturesKey))
+		members, err := s.Members(FeaturesKey)
+		require.NoError(t, err)
+		require.Equal(t, []string{"test"}, members)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.BoolGate{}, g[0])
+		b := g[0].(gates.BoolGate)
+		require.Equal(t, true, b.BoolValue())
+
+		err = driver.Disable(feat, gates.NewBoolGate(false))
+		require.NoError(t, err)
+		require.False(t, s.Exists("test"))
+
+		g, err = driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	s.FlushAll()
+
+	t.Run("enable for actor", func(t *testing.T) {
+		actor := testhelpers.Actor{ID: "User;1"}
+
+		gate := gates.NewActorGate(gates.NewSet(actor.ID))
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+		require.Equal(t, "1", s.HGet("test", "actors/User;1"))
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.ActorGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.ActorGate{}, g[0])
+		b := g[0].(gates.ActorGate)
+
+		set := b.SetValue()
+		require.Len(t, set, 1)
+		require.Contains(t, set, actor.FlipperID())
+
+		err = driver.Disable(feat, gate)
+		require.NoError(t, err)
+
+		g, err = driver.Get(feat, []gates.GateKey{gates.ActorGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	s.FlushAll()
+
+	t.Run("enable for groups", func(t *testing.T) {
+		gate := gates.NewGroupGate(gates.NewSet("admins"))
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+		require.Equal(t, "1", s.HGet("test", "groups/admins"))
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.GroupGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.GroupGate{}, g[0])
+		b := g[0].(gates.GroupGate)
+
+		set := b.SetValue()
+		require.Len(t, set, 1)
+		require.Contains(t, set, "admins")
+	})
+
+	s.FlushAll()
+
+	t.Run("enable for percentage of actors", func(t *testing.T) {
+		feat := feature.NewFeature("test")
+		gate := gates.NewPercentageOfActorsGate(30)
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+		require.Equal(t, "30", s.HGet("test", "percentage_of_actors"))
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.PercentageOfActorsGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.PercentageOfActorsGate{}, g[0])
+		b := g[0].(gates.PercentageOfActorsGate)
+
+		require.Equal(t, 30, b.IntValue())
+	})
+
+	s.FlushAll()
+
+	t.Run("enable for percentage of time", func(t *testing.T) {
+		feat := feature.NewFeature("test")
+		gate := gates.NewPercentageOfTimeGate(30)
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+		require.Equal(t, "30", s.HGet("test", "percentage_of_time"))
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.PercentageOfTimeGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.PercentageOfTimeGate{}, g[0])
+		b := g[0].(gates.PercentageOfTimeGate)
+
+		require.Equal(t, 30, b.IntValue())
+	})
+
+	s.FlushAll()
+
+	t.Run("read gates written by the ruby adapter", func(t *testing.T) {
+		s.SetAdd(FeaturesKey, "search")
+		s.HSet("search", "actors/User;1", "1")
+		s.HSet("search", "actors/User;2", "1")
+		s.HSet("search", "groups/admins", "1")
+		s.HSet("search", "percentage_of_actors", "10")
+		s.HSet("search", "percentage_of_time", "12.5")
+
+		feat := feature.NewFeature("search")
+		g, err := driver.Get(feat, []gates.GateKey{
+			gates.BoolGateKey,
+			gates.ActorGateKey,
+			gates.GroupGateKey,
+			gates.PercentageOfActorsGateKey,
+			gates.PercentageOfTimeGateKey,
+		})
+		require.NoError(t, err)
+		require.Len(t, g, 4)
+
+		require.Equal(t, gates.NewSet("User;1", "User;2"), g[0].(gates.ActorGate).SetValue())
+		require.Equal(t, gates.NewSet("admins"), g[1].(gates.GroupGate).SetValue())
+		require.Equal(t, 10, g[2].(gates.PercentageOfActorsGate).IntValue())
+		require.Equal(t, 12.5, g[3].(gates.PercentageOfTimeGate).FloatValue())
+
+		for _, p := range []float64{12.5, 0.5} {
+			s.HSet("search", "percentage_of_actors", strconv.FormatFloat(p, 'f', -1, 64))
+			g, err = driver.Get(feat, []gates.GateKey{gates.PercentageOfActorsGateKey})
+			require.NoError(t, err)
+			require.Equal(t, []gates.Gate{gates.NewFractionalPercentageOfActorsGate(p)}, g)
+		}
+	})
+
+	t.Run("list features", func(t *testing.T) {
+		s.FlushAll()
+
+		err := driver.Add(feature.NewFeature("search"))
+		require.NoError(t, err)
+		err = driver.Enable(feature.NewFeature("checkout"), gates.NewBoolGate(true))
+		require.NoError(t, err)
+
+		features, err := driver.Features()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []feature.Feature{feature.NewFeature("search"), feature.NewFeature("checkout")}, features)
+
+		err = driver.Clear(feature.NewFeature("checkout"))
+		require.NoError(t, err)
+
+		g, err := driver.Get(feature.NewFeature("checkout"), []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		err = driver.Remove(feature.NewFeature("search"))
+		require.NoError(t, err)
+
+		features, err = driver.Features()
+		require.NoError(t, err)
+		require.Equal(t, []feature.Feature{feature.NewFeature("checkout")}, features)
+	})
+
+	s.FlushAll()
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		feat := feature.NewFeature("test")
+		_, err := driver.GetContext(ctx, feat, []gates.GateKey{gates.BoolGateKey})
+		require.Error(t, err)
+
+		err = driver.EnableContext(ctx, feat, gates.NewBoolGate(true))
+		require.Error(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		s.FlushAll()
+		d := NewDriver()
+		require.NoError(t, d.Configure(map[string]interface{}{"url": "redis://" + s.Addr()}))
+		return d
+	})
+}
+
+func TestGateConformance(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	newDriver := func(t *testing.T) driver.Driver {
+		s.FlushAll()
+		d := NewDriver()
+		require.NoError(t, d.Configure(map[string]interface{}{"url": "redis://" + s.Addr()}))
+		return d
+	}
+
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
+}
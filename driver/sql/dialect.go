@@ -0,0 +1,91 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect identifies the SQL flavor used by the database.
+// Dialects differ in how they quote identifiers,
+// bind parameters and ignore duplicated rows.
+type Dialect string
+
+const (
+	// Postgres is the dialect for PostgreSQL databases.
+	Postgres Dialect = "postgres"
+	// MySQL is the dialect for MySQL and MariaDB databases.
+	MySQL Dialect = "mysql"
+	// SQLite is the dialect for SQLite databases.
+	SQLite Dialect = "sqlite"
+)
+
+// dialectFor returns the dialect used by a database/sql driver name.
+func dialectFor(driverName string) (Dialect, bool) {
+	switch strings.ToLower(driverName) {
+	case "postgres", "postgresql", "pgx":
+		return Postgres, true
+	case "mysql":
+		return MySQL, true
+	case "sqlite", "sqlite3":
+		return SQLite, true
+	}
+	return "", false
+}
+
+func (d Dialect) valid() bool {
+	_, ok := dialectFor(string(d))
+	return ok
+}
+
+// quote escapes an identifier.
+// The column `key` is a reserved word in MySQL, so every column is quoted.
+func (d Dialect) quote(ident string) string {
+	if d == MySQL {
+		return "`" + ident + "`"
+	}
+	return `"` + ident + `"`
+}
+
+// bind returns the placeholder for the nth parameter, starting at 1.
+func (d Dialect) bind(n int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// insertIgnore builds an insert statement that doesn't fail
+// when the row violates a unique index.
+func (d Dialect) insertIgnore(table string, columns ...string) string {
+	cols := make([]string, 0, len(columns)+2)
+	vals := make([]string, 0, len(columns)+2)
+	for i, c := range columns {
+		cols = append(cols, d.quote(c))
+		vals = append(vals, d.bind(i+1))
+	}
+	cols = append(cols, d.quote("created_at"), d.quote("updated_at"))
+	vals = append(vals, "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP")
+
+	insert := "INSERT INTO"
+	var suffix string
+	switch d {
+	case MySQL:
+		insert = "INSERT IGNORE INTO"
+	case SQLite:
+		insert = "INSERT OR IGNORE INTO"
+	case Postgres:
+		suffix = " ON CONFLICT DO NOTHING"
+	}
+
+	return fmt.Sprintf("%s %s (%s) VALUES (%s)%s",
+		insert, table, strings.Join(cols, ", "), strings.Join(vals, ", "), suffix)
+}
+
+// where builds a condition that matches every column with a parameter.
+func (d Dialect) where(columns ...string) string {
+	conds := make([]string, 0, len(columns))
+	for i, c := range columns {
+		conds = append(conds, fmt.Sprintf("%s = %s", d.quote(c), d.bind(i+1)))
+	}
+	return strings.Join(conds, " AND ")
+}
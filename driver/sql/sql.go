@@ -0,0 +1,331 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FeaturesTable is the table where flipper-active_record keeps the feature names.
+	FeaturesTable = "flipper_features"
+	// GatesTable is the table where flipper-active_record keeps the gate values.
+	GatesTable = "flipper_gates"
+
+	trueValue = "true"
+)
+
+type config struct {
+	Driver  string `mapstructure:"driver"`
+	DSN     string `mapstructure:"dsn"`
+	Dialect string `mapstructure:"dialect"`
+}
+
+// Driver is a store driver that keeps features and gates in a SQL database.
+// It uses the same schema as the Ruby flipper-active_record adapter:
+//   - flipper_features stores one row per feature name in the column "key".
+//   - flipper_gates stores one row per gate value, with the columns
+//     "feature_key", "key" and "value". Set gates use one row per value.
+type Driver struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewDriver initializes a new SQL driver.
+func NewDriver() *Driver {
+	return &Driver{}
+}
+
+// NewDriverWithDB initializes a new SQL driver with a given database handle.
+// This factory allows you to reuse a database open in your program.
+func NewDriverWithDB(db *sql.DB, dialect Dialect) *Driver {
+	return &Driver{db, dialect}
+}
+
+// Configure configures the SQL driver.
+// These are the options for this driver:
+//   - driver: name of the database/sql driver registered in your program (required)
+//   - dsn: data source name for the database/sql driver (required)
+//   - dialect: postgres, mysql or sqlite (optional - default inferred from the driver name)
+// This function doesn't do anything if the driver already has a database configured.
+func (a *Driver) Configure(c map[string]interface{}) error {
+	if a.db != nil {
+		return nil
+	}
+
+	var conf config
+	if err := mapstructure.Decode(c, &conf); err != nil {
+		return errors.Wrap(err, "error decoding SQL's driver configuration")
+	}
+
+	if conf.Driver == "" || conf.DSN == "" {
+		return errors.New("invalid driver name or DSN for SQL's driver")
+	}
+
+	dialect := Dialect(conf.Dialect)
+	if dialect == "" {
+		dialect, _ = dialectFor(conf.Driver)
+	}
+	if !dialect.valid() {
+		return errors.Errorf("unsupported dialect for SQL's driver: %q", dialect)
+	}
+
+	db, err := sql.Open(conf.Driver, conf.DSN)
+	if err != nil {
+		return errors.Wrap(err, "error opening SQL database")
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return errors.Wrap(err, "error connecting to SQL database")
+	}
+
+	a.db = db
+	a.dialect = dialect
+
+	return nil
+}
+
+// Enable opens a feature for a give gate.
+// Enabling the boolean gate clears any other gate,
+// the same way the Ruby adapter does.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate.
+// The transaction is rolled back when the context is done.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, a.dialect.insertIgnore(FeaturesTable, "key"), feature.Name); err != nil {
+			return err
+		}
+
+		key := string(gate.Key())
+
+		if g, ok := gate.(gates.IntGateType); ok {
+			return a.replace(ctx, tx, feature, key, strconv.FormatFloat(gates.NumberValue(g), 'f', -1, 64))
+		} else if _, ok := gate.(gates.BoolGateType); ok {
+			if err := a.clear(ctx, tx, feature); err != nil {
+				return err
+			}
+			return a.insert(ctx, tx, feature, key, trueValue)
+		} else if g, ok := gate.(gates.SetGateType); ok {
+			for v := range g.SetValue() {
+				if err := a.insert(ctx, tx, feature, key, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		return errors.Errorf("unsupported data type: %v", gate.Key())
+	})
+}
+
+// Disable closes a feature for a given gate.
+// Disabling the boolean gate removes every gate for the feature,
+// the same way the Ruby adapter does.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate.
+// The transaction is rolled back when the context is done.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	return a.transaction(ctx, func(tx *sql.Tx) error {
+		key := string(gate.Key())
+
+		if g, ok := gate.(gates.IntGateType); ok {
+			return a.replace(ctx, tx, feature, key, strconv.FormatFloat(gates.NumberValue(g), 'f', -1, 64))
+		} else if _, ok := gate.(gates.BoolGateType); ok {
+			return a.clear(ctx, tx, feature)
+		} else if g, ok := gate.(gates.SetGateType); ok {
+			q := fmt.Sprintf("DELETE FROM %s WHERE %s", GatesTable, a.dialect.where("feature_key", "key", "value"))
+			for v := range g.SetValue() {
+				if _, err := tx.ExecContext(ctx, q, feature.Name, key, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		return errors.Errorf("unsupported data type: %v", gate.Key())
+	})
+}
+
+// Get returns the enabled gates for a feature given a set of gate keys.
+// Gates are skipped if they are not set for a feature.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.GetContext(context.Background(), feature, keys)
+}
+
+// GetContext returns the enabled gates for a feature given a set of gate keys.
+// The query is cancelled when the context is done.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	q := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s",
+		a.dialect.quote("key"), a.dialect.quote("value"), GatesTable, a.dialect.where("feature_key"))
+
+	rows, err := a.db.QueryContext(ctx, q, feature.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	doc := make(map[string][]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		doc[k] = append(doc[k], v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var g []gates.Gate
+
+	for _, t := range keys {
+		values, ok := doc[string(t)]
+		if !ok {
+			continue
+		}
+
+		switch t {
+		case gates.BoolGateKey:
+			if values[0] == trueValue {
+				g = append(g, gates.NewBoolGate(true))
+			}
+		case gates.ActorGateKey:
+			g = append(g, gates.NewActorGate(gates.NewSet(values...)))
+		case gates.GroupGateKey:
+			g = append(g, gates.NewGroupGate(gates.NewSet(values...)))
+		case gates.ExclusionGateKey:
+			g = append(g, gates.NewExclusionGate(gates.NewSet(values...)))
+		case gates.PercentageOfActorsGateKey:
+			f, err := strconv.ParseFloat(values[0], 64)
+			if err != nil {
+				return nil, errors.Errorf("unexpected number value stored: %v", values[0])
+			}
+			if f > 0 {
+				g = append(g, gates.NewFractionalPercentageOfActorsGate(f))
+			}
+		case gates.PercentageOfTimeGateKey:
+			f, err := strconv.ParseFloat(values[0], 64)
+			if err != nil {
+				return nil, errors.Errorf("unexpected number value stored: %v", values[0])
+			}
+			if f > 0 {
+				g = append(g, gates.NewFractionalPercentageOfTimeGate(f))
+			}
+		default:
+			return nil, errors.Errorf("unsupported gate: %v", t)
+		}
+	}
+
+	return g, nil
+}
+
+// Features returns every feature known by the driver.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	q := fmt.Sprintf("SELECT %s FROM %s", a.dialect.quote("key"), FeaturesTable)
+
+	rows, err := a.db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var f []feature.Feature
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		f = append(f, feature.NewFeature(name))
+	}
+
+	return f, rows.Err()
+}
+
+// Add makes a feature known by the driver without enabling it.
+func (a *Driver) Add(feature feature.Feature) error {
+	_, err := a.db.Exec(a.dialect.insertIgnore(FeaturesTable, "key"), feature.Name)
+	return err
+}
+
+// Remove clears every gate for a feature and forgets about it.
+func (a *Driver) Remove(feature feature.Feature) error {
+	ctx := context.Background()
+	return a.transaction(ctx, func(tx *sql.Tx) error {
+		q := fmt.Sprintf("DELETE FROM %s WHERE %s", FeaturesTable, a.dialect.where("key"))
+		if _, err := tx.ExecContext(ctx, q, feature.Name); err != nil {
+			return err
+		}
+		return a.clear(ctx, tx, feature)
+	})
+}
+
+// Clear removes every gate value for a feature.
+func (a *Driver) Clear(feature feature.Feature) error {
+	ctx := context.Background()
+	return a.transaction(ctx, func(tx *sql.Tx) error {
+		return a.clear(ctx, tx, feature)
+	})
+}
+
+func (a *Driver) transaction(ctx context.Context, f func(*sql.Tx) error) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *Driver) insert(ctx context.Context, tx *sql.Tx, feature feature.Feature, key, value string) error {
+	_, err := tx.ExecContext(ctx, a.dialect.insertIgnore(GatesTable, "feature_key", "key", "value"), feature.Name, key, value)
+	return err
+}
+
+// replace removes every row for a gate and inserts a new one with the value.
+// Empty and zero values are not stored, those gates are never open.
+func (a *Driver) replace(ctx context.Context, tx *sql.Tx, feature feature.Feature, key, value string) error {
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s", GatesTable, a.dialect.where("feature_key", "key"))
+	if _, err := tx.ExecContext(ctx, q, feature.Name, key); err != nil {
+		return err
+	}
+	if value == "" || value == "0" {
+		return nil
+	}
+	return a.insert(ctx, tx, feature, key, value)
+}
+
+func (a *Driver) clear(ctx context.Context, tx *sql.Tx, feature feature.Feature) error {
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s", GatesTable, a.dialect.where("feature_key"))
+	_, err := tx.ExecContext(ctx, q, feature.Name)
+	return err
+}
+
+func init() {
+	driver.Register("sql", func(config map[string]interface{}) (driver.Driver, error) {
+		d := NewDriver()
+		if err := d.Configure(config); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
+}
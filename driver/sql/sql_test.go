@@ -0,0 +1,293 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/calavera/go-flipper/actor/testhelpers"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema mirrors the migration generated by flipper-active_record.
+var schema = []string{
+	`CREATE TABLE flipper_features (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key VARCHAR(255) NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`,
+	`CREATE UNIQUE INDEX index_flipper_features_on_key ON flipper_features (key)`,
+	`CREATE TABLE flipper_gates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feature_key VARCHAR(255) NOT NULL,
+		key VARCHAR(255) NOT NULL,
+		value VARCHAR(255),
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`,
+	`CREATE UNIQUE INDEX index_flipper_gates_on_feature_key_and_key_and_value ON flipper_gates (feature_key, key, value)`,
+}
+
+func TestDialect(t *testing.T) {
+	require.Equal(t,
+		`INSERT INTO flipper_gates ("feature_key", "key", "created_at", "updated_at") VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) ON CONFLICT DO NOTHING`,
+		Postgres.insertIgnore(GatesTable, "feature_key", "key"))
+	require.Equal(t,
+		"INSERT IGNORE INTO flipper_gates (`feature_key`, `key`, `created_at`, `updated_at`) VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)",
+		MySQL.insertIgnore(GatesTable, "feature_key", "key"))
+	require.Equal(t,
+		`INSERT OR IGNORE INTO flipper_gates ("feature_key", "key", "created_at", "updated_at") VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		SQLite.insertIgnore(GatesTable, "feature_key", "key"))
+
+	require.Equal(t, `"feature_key" = $1 AND "key" = $2`, Postgres.where("feature_key", "key"))
+	require.Equal(t, "`feature_key` = ? AND `key` = ?", MySQL.where("feature_key", "key"))
+}
+
+func TestSQL(t *testing.T) {
+	t.Run("configure", func(t *testing.T) {
+		d := NewDriver()
+		err := d.Configure(map[string]interface{}{
+			"driver": "sqlite3",
+			"dsn":    ":memory:",
+		})
+		require.NoError(t, err)
+		require.Equal(t, SQLite, d.dialect)
+	})
+
+	t.Run("configure with unknown dialect", func(t *testing.T) {
+		d := NewDriver()
+		err := d.Configure(map[string]interface{}{
+			"driver": "oracle",
+			"dsn":    "oracle://localhost",
+		})
+		require.Error(t, err)
+	})
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	for _, s := range schema {
+		_, err := db.Exec(s)
+		require.NoError(t, err)
+	}
+
+	reset := func() {
+		db.Exec("DELETE FROM flipper_features")
+		db.Exec("DELETE FROM flipper_gates")
+	}
+
+	driver := NewDriverWithDB(db, SQLite)
+
+	t.Run("enable for system", func(t *testing.T) {
+		gate := gates.NewBoolGate(true)
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+
+		var key string
+		require.NoError(t, db.QueryRow("SELECT key FROM flipper_features").Scan(&key))
+		require.Equal(t, "test", key)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.BoolGate{}, g[0])
+		b := g[0].(gates.BoolGate)
+		require.Equal(t, true, b.BoolValue())
+
+		err = driver.Disable(feat, gates.NewBoolGate(false))
+		require.NoError(t, err)
+
+		g, err = driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	reset()
+
+	t.Run("enable for actor", func(t *testing.T) {
+		actor := testhelpers.Actor{ID: "User;1"}
+
+		gate := gates.NewActorGate(gates.NewSet(actor.ID))
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+
+		// enabling twice doesn't duplicate rows.
+		err = driver.Enable(feat, gates.NewActorGate(gates.NewSet(actor.ID, "User;2")))
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM flipper_gates WHERE key = 'actors'").Scan(&count))
+		require.Equal(t, 2, count)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.ActorGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.ActorGate{}, g[0])
+		b := g[0].(gates.ActorGate)
+		require.Equal(t, gates.NewSet("User;1", "User;2"), b.SetValue())
+
+		err = driver.Disable(feat, gates.NewActorGate(gates.NewSet("User;2")))
+		require.NoError(t, err)
+
+		g, err = driver.Get(feat, []gates.GateKey{gates.ActorGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+		require.Equal(t, gates.NewSet("User;1"), g[0].(gates.ActorGate).SetValue())
+	})
+
+	reset()
+
+	t.Run("enable for groups", func(t *testing.T) {
+		gate := gates.NewGroupGate(gates.NewSet("admins"))
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.GroupGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.GroupGate{}, g[0])
+		b := g[0].(gates.GroupGate)
+
+		set := b.SetValue()
+		require.Len(t, set, 1)
+		require.Contains(t, set, "admins")
+	})
+
+	reset()
+
+	t.Run("enable for percentage of actors", func(t *testing.T) {
+		feat := feature.NewFeature("test")
+
+		err := driver.Enable(feat, gates.NewPercentageOfActorsGate(10))
+		require.NoError(t, err)
+		err = driver.Enable(feat, gates.NewPercentageOfActorsGate(30))
+		require.NoError(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.PercentageOfActorsGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.PercentageOfActorsGate{}, g[0])
+		b := g[0].(gates.PercentageOfActorsGate)
+
+		require.Equal(t, 30, b.IntValue())
+
+		// the Ruby adapter can store fractional percentages.
+		for _, p := range []float64{12.5, 0.5} {
+			_, err = db.Exec("UPDATE flipper_gates SET value = ? WHERE key = 'percentage_of_actors'", strconv.FormatFloat(p, 'f', -1, 64))
+			require.NoError(t, err)
+
+			g, err = driver.Get(feat, []gates.GateKey{gates.PercentageOfActorsGateKey})
+			require.NoError(t, err)
+			require.Equal(t, []gates.Gate{gates.NewFractionalPercentageOfActorsGate(p)}, g)
+		}
+	})
+
+	reset()
+
+	t.Run("enable for percentage of time", func(t *testing.T) {
+		feat := feature.NewFeature("test")
+		gate := gates.NewPercentageOfTimeGate(30)
+
+		err := driver.Enable(feat, gate)
+		require.NoError(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.PercentageOfTimeGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 1)
+
+		require.IsType(t, gates.PercentageOfTimeGate{}, g[0])
+		b := g[0].(gates.PercentageOfTimeGate)
+
+		require.Equal(t, 30, b.IntValue())
+	})
+
+	t.Run("list features", func(t *testing.T) {
+		reset()
+
+		err := driver.Add(feature.NewFeature("search"))
+		require.NoError(t, err)
+		err = driver.Enable(feature.NewFeature("checkout"), gates.NewBoolGate(true))
+		require.NoError(t, err)
+
+		features, err := driver.Features()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []feature.Feature{feature.NewFeature("search"), feature.NewFeature("checkout")}, features)
+
+		err = driver.Clear(feature.NewFeature("checkout"))
+		require.NoError(t, err)
+
+		g, err := driver.Get(feature.NewFeature("checkout"), []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		err = driver.Remove(feature.NewFeature("search"))
+		require.NoError(t, err)
+
+		features, err = driver.Features()
+		require.NoError(t, err)
+		require.Equal(t, []feature.Feature{feature.NewFeature("checkout")}, features)
+	})
+
+	reset()
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		feat := feature.NewFeature("test")
+		_, err := driver.GetContext(ctx, feat, []gates.GateKey{gates.BoolGateKey})
+		require.Error(t, err)
+
+		err = driver.EnableContext(ctx, feat, gates.NewBoolGate(true))
+		require.Error(t, err)
+
+		g, err := driver.Get(feat, []gates.GateKey{gates.BoolGateKey})
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, newSQLiteDriver)
+}
+
+func TestGateConformance(t *testing.T) {
+	t.Run("exclusion gate", func(t *testing.T) {
+		drivertest.RunGateConformance(t, newSQLiteDriver,
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")))
+	})
+}
+
+func newSQLiteDriver(t *testing.T) driver.Driver {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	for _, s := range schema {
+		_, err := db.Exec(s)
+		require.NoError(t, err)
+	}
+
+	return NewDriverWithDB(db, SQLite)
+}
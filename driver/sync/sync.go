@@ -0,0 +1,328 @@
+package sync
+
+import (
+	"context"
+	gosync "sync"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const defaultInterval = 10 * time.Second
+
+// syncKeys are the gates loaded from the upstream driver.
+var syncKeys = []gates.GateKey{
+	gates.BoolGateKey,
+	gates.ActorGateKey,
+	gates.GroupGateKey,
+	gates.PercentageOfActorsGateKey,
+	gates.PercentageOfTimeGateKey,
+	gates.ScheduleGateKey,
+	gates.RampGateKey,
+	gates.ExclusionGateKey,
+}
+
+type config struct {
+	Driver   string                 `mapstructure:"driver"`
+	Config   map[string]interface{} `mapstructure:"config"`
+	Interval string                 `mapstructure:"interval"`
+}
+
+// Driver is a store driver that serves features from memory
+// and keeps them in sync with an upstream driver.
+// Every interval, it loads all the features from the upstream driver
+// into a new memory driver and swaps it with the current one.
+// Writes are sent to the upstream driver and applied locally,
+// writes made by other processes are visible after the next sync.
+// The upstream driver must implement the driver.Lister interface.
+// It's safe for concurrent use.
+type Driver struct {
+	upstream driver.Driver
+	interval time.Duration
+	now      func() time.Time
+
+	mu        gosync.RWMutex
+	local     *memory.Driver
+	lastSync  time.Time
+	lastError error
+
+	runMu gosync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewDriver initializes a sync driver for an upstream driver.
+// A zero interval uses the default, 10 seconds.
+// The driver doesn't load any feature until Sync or Start are called.
+func NewDriver(upstream driver.Driver, interval time.Duration) *Driver {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &Driver{
+		upstream: upstream,
+		interval: interval,
+		now:      time.Now,
+		local:    memory.NewDriver(),
+	}
+}
+
+// Configure configures the upstream driver.
+func (a *Driver) Configure(config map[string]interface{}) error {
+	return a.upstream.Configure(config)
+}
+
+// Start loads the features from the upstream driver and
+// starts syncing them in the background every interval.
+// The background sync keeps running even if the first sync fails,
+// its error is returned and it's also available in LastError.
+// Calling Start on a started driver only syncs the features.
+func (a *Driver) Start() error {
+	err := a.Sync()
+
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.stop == nil {
+		a.stop = make(chan struct{})
+		a.done = make(chan struct{})
+		go a.run(a.stop, a.done)
+	}
+
+	return err
+}
+
+// Stop stops the background sync and waits for it to finish.
+// It doesn't do anything if the driver is not started.
+func (a *Driver) Stop() {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.stop == nil {
+		return
+	}
+
+	close(a.stop)
+	<-a.done
+	a.stop = nil
+	a.done = nil
+}
+
+// Sync loads every feature from the upstream driver
+// and replaces the local features with them.
+// The local features are not modified if the sync fails.
+func (a *Driver) Sync() error {
+	local, err := a.load()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastError = err
+	if err != nil {
+		return err
+	}
+
+	a.local = local
+	a.lastSync = a.now()
+	return nil
+}
+
+// LastSync returns the time of the last successful sync.
+// It returns the zero time if the features were never synced.
+func (a *Driver) LastSync() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.lastSync
+}
+
+// LastError returns the error of the last sync.
+// It returns nil if the last sync succeeded.
+func (a *Driver) LastError() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.lastError
+}
+
+// Enable opens a feature for a give gate in the upstream driver
+// and in the local features.
+func (a *Driver) Enable(feature feature.Feature, gate gates.Gate) error {
+	return a.EnableContext(context.Background(), feature, gate)
+}
+
+// EnableContext opens a feature for a give gate in the upstream driver
+// and in the local features.
+func (a *Driver) EnableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := driver.EnableContext(ctx, a.upstream, feature, gate); err != nil {
+		return err
+	}
+	return a.localDriver().Enable(feature, gate)
+}
+
+// Disable closes a feature for a given gate in the upstream driver
+// and in the local features.
+func (a *Driver) Disable(feature feature.Feature, gate gates.Gate) error {
+	return a.DisableContext(context.Background(), feature, gate)
+}
+
+// DisableContext closes a feature for a given gate in the upstream driver
+// and in the local features.
+func (a *Driver) DisableContext(ctx context.Context, feature feature.Feature, gate gates.Gate) error {
+	if err := driver.DisableContext(ctx, a.upstream, feature, gate); err != nil {
+		return err
+	}
+	return a.localDriver().Disable(feature, gate)
+}
+
+// Get returns the enabled gates for a feature from the local features.
+func (a *Driver) Get(feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.localDriver().Get(feature, keys)
+}
+
+// GetContext returns the enabled gates for a feature from the local features.
+func (a *Driver) GetContext(ctx context.Context, feature feature.Feature, keys []gates.GateKey) ([]gates.Gate, error) {
+	return a.localDriver().GetContext(ctx, feature, keys)
+}
+
+// Features returns every feature in the local features.
+func (a *Driver) Features() ([]feature.Feature, error) {
+	return a.localDriver().Features()
+}
+
+// Add makes a feature known by the upstream driver and the local features.
+func (a *Driver) Add(feature feature.Feature) error {
+	l, ok := a.upstream.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	if err := l.Add(feature); err != nil {
+		return err
+	}
+	return a.localDriver().Add(feature)
+}
+
+// Remove removes a feature from the upstream driver and the local features.
+func (a *Driver) Remove(feature feature.Feature) error {
+	l, ok := a.upstream.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	if err := l.Remove(feature); err != nil {
+		return err
+	}
+	return a.localDriver().Remove(feature)
+}
+
+// Clear removes every gate for a feature from the upstream driver and the local features.
+func (a *Driver) Clear(feature feature.Feature) error {
+	l, ok := a.upstream.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+	if err := l.Clear(feature); err != nil {
+		return err
+	}
+	return a.localDriver().Clear(feature)
+}
+
+func (a *Driver) localDriver() *memory.Driver {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.local
+}
+
+func (a *Driver) load() (*memory.Driver, error) {
+	l, ok := a.upstream.(driver.Lister)
+	if !ok {
+		return nil, driver.ErrListingNotSupported
+	}
+
+	features, err := l.Features()
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing upstream features")
+	}
+
+	local := memory.NewDriver()
+	for _, f := range features {
+		if err := local.Add(f); err != nil {
+			return nil, err
+		}
+
+		g, err := a.upstream.Get(f, syncKeys)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading upstream feature %s", f.Name)
+		}
+
+		for _, gate := range g {
+			if err := local.Enable(f, gate); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return local, nil
+}
+
+func (a *Driver) run(stop, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			a.Sync()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// init registers the driver with the name "sync".
+// These are the options for this driver:
+//   - driver: name of the registered upstream driver (required)
+//   - config: configuration for the upstream driver (optional)
+//   - interval: time between syncs, like "30s" (optional - default "10s")
+//
+// Drivers created by the registry are started right away.
+func init() {
+	driver.Register("sync", func(c map[string]interface{}) (driver.Driver, error) {
+		var conf config
+		if err := mapstructure.Decode(c, &conf); err != nil {
+			return nil, errors.Wrap(err, "error decoding sync's driver configuration")
+		}
+
+		factory := driver.Lookup(conf.Driver)
+		if factory == nil {
+			return nil, errors.Errorf("invalid driver to sync: %q", conf.Driver)
+		}
+
+		var interval time.Duration
+		if conf.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(conf.Interval); err != nil {
+				return nil, errors.Wrap(err, "invalid interval for sync's driver")
+			}
+		}
+
+		upstream, err := factory(conf.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		d := NewDriver(upstream, interval)
+		if err := d.Start(); err != nil {
+			d.Stop()
+			return nil, err
+		}
+		return d, nil
+	})
+}
@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper"
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/drivertest"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+var errUpstream = errors.New("upstream is down")
+
+// failingDriver is an upstream driver that can't list its features.
+type failingDriver struct {
+	*memory.Driver
+	fail bool
+}
+
+func (d *failingDriver) Features() ([]feature.Feature, error) {
+	if d.fail {
+		return nil, errUpstream
+	}
+	return d.Driver.Features()
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.RunConformance(t, func(t *testing.T) driver.Driver {
+		return NewDriver(memory.NewDriver(), time.Minute)
+	})
+}
+
+func TestSync(t *testing.T) {
+	search := feature.NewFeature("search")
+
+	t.Run("loads upstream features", func(t *testing.T) {
+		upstream := memory.NewDriver()
+		require.NoError(t, upstream.Enable(search, gates.NewBoolGate(true)))
+		require.NoError(t, upstream.Enable(search, gates.NewActorGate(gates.NewSet("User;1"))))
+		require.NoError(t, upstream.Enable(search, gates.NewFractionalPercentageOfTimeGate(12.5)))
+		require.NoError(t, upstream.Add(feature.NewFeature("unused")))
+
+		d := NewDriver(upstream, time.Minute)
+		require.True(t, d.LastSync().IsZero())
+
+		g, err := d.Get(search, syncKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+
+		now := time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC)
+		d.now = func() time.Time { return now }
+
+		require.NoError(t, d.Sync())
+		require.Equal(t, now, d.LastSync())
+		require.NoError(t, d.LastError())
+
+		g, err = d.Get(search, syncKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewBoolGate(true),
+			gates.NewActorGate(gates.NewSet("User;1")),
+			gates.NewFractionalPercentageOfTimeGate(12.5),
+		}, g)
+
+		f, err := d.Features()
+		require.NoError(t, err)
+		require.Len(t, f, 2)
+
+		require.NoError(t, upstream.Remove(search))
+		require.NoError(t, d.Sync())
+
+		g, err = d.Get(search, syncKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	t.Run("forwards writes upstream", func(t *testing.T) {
+		upstream := memory.NewDriver()
+		d := NewDriver(upstream, time.Minute)
+
+		require.NoError(t, d.Enable(search, gates.NewGroupGate(gates.NewSet("admins"))))
+		require.NoError(t, d.Add(feature.NewFeature("unused")))
+
+		for _, s := range []driver.Driver{upstream, d} {
+			g, err := s.Get(search, syncKeys)
+			require.NoError(t, err)
+			require.Equal(t, []gates.Gate{gates.NewGroupGate(gates.NewSet("admins"))}, g)
+
+			f, err := s.(driver.Lister).Features()
+			require.NoError(t, err)
+			require.Len(t, f, 2)
+		}
+
+		require.NoError(t, d.Disable(search, gates.NewGroupGate(gates.NewSet("admins"))))
+
+		for _, s := range []driver.Driver{upstream, d} {
+			g, err := s.Get(search, syncKeys)
+			require.NoError(t, err)
+			require.Len(t, g, 0)
+		}
+	})
+
+	t.Run("keeps local features when sync fails", func(t *testing.T) {
+		upstream := &failingDriver{Driver: memory.NewDriver()}
+		require.NoError(t, upstream.Enable(search, gates.NewBoolGate(true)))
+
+		d := NewDriver(upstream, time.Minute)
+		require.NoError(t, d.Sync())
+		lastSync := d.LastSync()
+
+		upstream.fail = true
+		err := d.Sync()
+		require.Error(t, err)
+		require.Equal(t, err, d.LastError())
+		require.Equal(t, lastSync, d.LastSync())
+
+		g, err := d.Get(search, syncKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{gates.NewBoolGate(true)}, g)
+
+		upstream.fail = false
+		require.NoError(t, d.Sync())
+		require.NoError(t, d.LastError())
+	})
+
+	t.Run("upstream without listing", func(t *testing.T) {
+		d := NewDriver(struct{ driver.Driver }{memory.NewDriver()}, time.Minute)
+		require.Equal(t, driver.ErrListingNotSupported, d.Sync())
+		require.Equal(t, driver.ErrListingNotSupported, d.Add(search))
+	})
+
+	t.Run("background sync", func(t *testing.T) {
+		upstream := memory.NewDriver()
+		d := NewDriver(upstream, 10*time.Millisecond)
+
+		require.NoError(t, d.Start())
+		require.NoError(t, d.Start())
+		defer d.Stop()
+
+		require.NoError(t, upstream.Enable(search, gates.NewBoolGate(true)))
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			g, err := d.Get(search, syncKeys)
+			require.NoError(t, err)
+			if len(g) == 1 {
+				break
+			}
+			require.True(t, time.Now().Before(deadline), "features were not synced in the background")
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		d.Stop()
+		d.Stop()
+		lastSync := d.LastSync()
+		time.Sleep(30 * time.Millisecond)
+		require.Equal(t, lastSync, d.LastSync())
+	})
+}
+
+func TestRegister(t *testing.T) {
+	_, err := flipper.NewClient("sync", map[string]interface{}{"driver": "unknown"})
+	require.Error(t, err)
+
+	_, err = flipper.NewClient("sync", map[string]interface{}{"driver": "memory", "interval": "soon"})
+	require.Error(t, err)
+
+	c, err := flipper.NewClient("sync", map[string]interface{}{"driver": "memory", "interval": "1h"})
+	require.NoError(t, err)
+	require.NoError(t, c.Enable("search"))
+
+	ok, err := c.IsEnabled("search")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,425 @@
+// Package export dumps and loads every feature in a store
+// with the JSON format used by Flipper.export and Flipper.import in the Ruby gem:
+//
+//	{
+//	  "version": 1,
+//	  "features": {
+//	    "search": {
+//	      "boolean": "true",
+//	      "groups": ["admins"],
+//	      "actors": ["User;1"],
+//	      "percentage_of_actors": "25",
+//	      "percentage_of_time": null
+//	    }
+//	  }
+//	}
+//
+// Gates that the Ruby gem doesn't have are only included when they are set,
+// with times in RFC 3339 format and durations in the format of time.ParseDuration:
+//
+//	"schedule": {"start": "2018-11-01T00:00:00Z", "end": "2018-11-02T00:00:00Z"}
+//	"ramp": {"start": "2018-11-01T00:00:00Z", "duration": "72h0m0s", "from": 1, "to": 100}
+//	"excluded_actors": ["User;2"]
+//
+// Documents can be moved between stores and between the Go and Ruby implementations.
+// The Ruby gem ignores the gates it doesn't have.
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the export format.
+const Version = 1
+
+// Mode defines how imported features are combined with the features in a store.
+type Mode int
+
+const (
+	// Merge adds the gate values in the document to the store.
+	// Gate values and features that are not in the document are kept,
+	// except for features enabled by the boolean gate in the document,
+	// because enabling the boolean gate clears every other gate.
+	Merge Mode = iota
+	// Replace makes the store equal to the document.
+	// Gate values and features that are not in the document are removed.
+	Replace
+)
+
+var allKeys = []gates.GateKey{
+	gates.BoolGateKey,
+	gates.ActorGateKey,
+	gates.GroupGateKey,
+	gates.PercentageOfActorsGateKey,
+	gates.PercentageOfTimeGateKey,
+	gates.ScheduleGateKey,
+	gates.RampGateKey,
+	gates.ExclusionGateKey,
+}
+
+type document struct {
+	Version  int                     `json:"version"`
+	Features map[string]featureGates `json:"features"`
+}
+
+// featureGates uses the same keys and order as the Ruby gem.
+// Gates that the Ruby gem doesn't have go after them.
+type featureGates struct {
+	Boolean            value     `json:"boolean"`
+	Groups             []string  `json:"groups"`
+	Actors             []string  `json:"actors"`
+	PercentageOfActors value     `json:"percentage_of_actors"`
+	PercentageOfTime   value     `json:"percentage_of_time"`
+	Schedule           *schedule `json:"schedule,omitempty"`
+	Ramp               *ramp     `json:"ramp,omitempty"`
+	ExcludedActors     []string  `json:"excluded_actors,omitempty"`
+}
+
+// schedule is the time window of a schedule gate.
+// Empty times are open ended.
+type schedule struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// ramp is the value of a ramp gate.
+type ramp struct {
+	Start    string `json:"start,omitempty"`
+	Duration string `json:"duration"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+}
+
+// value is a gate value, exported as a string like the Ruby gem does.
+// Empty values are exported as null.
+// Documents can also use JSON booleans and numbers.
+type value string
+
+// MarshalJSON encodes a value as a string, or null when it's empty.
+func (v value) MarshalJSON() ([]byte, error) {
+	if v == "" {
+		return []byte("null"), nil
+	}
+	return json.Marshal(string(v))
+}
+
+// UnmarshalJSON decodes strings, booleans, numbers and null values.
+func (v *value) UnmarshalJSON(data []byte) error {
+	var i interface{}
+	if err := json.Unmarshal(data, &i); err != nil {
+		return err
+	}
+
+	switch t := i.(type) {
+	case nil:
+		*v = ""
+	case string:
+		*v = value(t)
+	case bool:
+		*v = value(strconv.FormatBool(t))
+	case float64:
+		*v = value(strconv.FormatFloat(t, 'f', -1, 64))
+	default:
+		return errors.Errorf("unexpected value %s", data)
+	}
+	return nil
+}
+
+// Export writes every feature in a store to w.
+// The driver must implement the driver.Lister interface.
+func Export(d driver.Driver, w io.Writer) error {
+	l, ok := d.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+
+	features, err := l.Features()
+	if err != nil {
+		return err
+	}
+
+	doc := document{
+		Version:  Version,
+		Features: make(map[string]featureGates, len(features)),
+	}
+
+	for _, f := range features {
+		g, err := d.Get(f, allKeys)
+		if err != nil {
+			return errors.Wrapf(err, "error exporting feature %s", f.Name)
+		}
+		doc.Features[f.Name] = exportGates(g)
+	}
+
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// Import reads features from r and saves them in a store.
+// The document is validated before modifying the store,
+// an invalid document doesn't change any feature.
+// The driver must implement the driver.Lister interface.
+func Import(d driver.Driver, r io.Reader, mode Mode) error {
+	l, ok := d.(driver.Lister)
+	if !ok {
+		return driver.ErrListingNotSupported
+	}
+
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return errors.Wrap(err, "invalid export document")
+	}
+	if doc.Version != Version {
+		return errors.Errorf("unsupported export version: %d", doc.Version)
+	}
+
+	names := make([]string, 0, len(doc.Features))
+	for name := range doc.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	imported := make(map[string][]gates.Gate, len(names))
+	for _, name := range names {
+		if name == "" {
+			return errors.New("invalid export document: feature names cannot be empty")
+		}
+
+		g, err := importGates(doc.Features[name])
+		if err != nil {
+			return errors.Wrapf(err, "invalid feature %q", name)
+		}
+		imported[name] = g
+	}
+
+	if mode == Replace {
+		current, err := l.Features()
+		if err != nil {
+			return err
+		}
+		for _, f := range current {
+			if _, ok := imported[f.Name]; !ok {
+				if err := l.Remove(f); err != nil {
+					return errors.Wrapf(err, "error removing feature %s", f.Name)
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		f := feature.NewFeature(name)
+
+		if mode == Replace {
+			if err := l.Clear(f); err != nil {
+				return errors.Wrapf(err, "error importing feature %s", name)
+			}
+		}
+		if err := l.Add(f); err != nil {
+			return errors.Wrapf(err, "error importing feature %s", name)
+		}
+
+		for _, g := range imported[name] {
+			if err := d.Enable(f, g); err != nil {
+				return errors.Wrapf(err, "error importing feature %s", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func exportGates(g []gates.Gate) featureGates {
+	fg := featureGates{
+		Groups: []string{},
+		Actors: []string{},
+	}
+
+	for _, gate := range g {
+		switch v := gate.(type) {
+		case gates.BoolGateType:
+			if v.BoolValue() {
+				fg.Boolean = "true"
+			}
+		case gates.SetGateType:
+			switch gate.Key() {
+			case gates.ActorGateKey:
+				fg.Actors = sortedValues(v.SetValue())
+			case gates.GroupGateKey:
+				fg.Groups = sortedValues(v.SetValue())
+			case gates.ExclusionGateKey:
+				fg.ExcludedActors = sortedValues(v.SetValue())
+			}
+		case gates.IntGateType:
+			n := value(strconv.FormatFloat(gates.NumberValue(v), 'f', -1, 64))
+			if gate.Key() == gates.PercentageOfActorsGateKey {
+				fg.PercentageOfActors = n
+			} else {
+				fg.PercentageOfTime = n
+			}
+		case gates.TimeRangeGateType:
+			start, end := v.TimeRange()
+			fg.Schedule = &schedule{Start: formatTime(start), End: formatTime(end)}
+		case gates.RampGateType:
+			start, duration, from, to := v.Ramp()
+			fg.Ramp = &ramp{Start: formatTime(start), Duration: duration.String(), From: from, To: to}
+		}
+	}
+
+	return fg
+}
+
+func importGates(fg featureGates) ([]gates.Gate, error) {
+	var g []gates.Gate
+
+	switch fg.Boolean {
+	case "", "false":
+	case "true":
+		g = append(g, gates.NewBoolGate(true))
+	default:
+		return nil, errors.Errorf("boolean must be true, false or null, found %q", fg.Boolean)
+	}
+
+	if len(fg.Actors) > 0 {
+		if err := validateSet("actors", fg.Actors); err != nil {
+			return nil, err
+		}
+		g = append(g, gates.NewActorGate(gates.NewSet(fg.Actors...)))
+	}
+
+	if len(fg.Groups) > 0 {
+		if err := validateSet("groups", fg.Groups); err != nil {
+			return nil, err
+		}
+		g = append(g, gates.NewGroupGate(gates.NewSet(fg.Groups...)))
+	}
+
+	p, err := percentage("percentage_of_actors", fg.PercentageOfActors)
+	if err != nil {
+		return nil, err
+	}
+	if p != math.Trunc(p) {
+		return nil, errors.Errorf("percentage_of_actors must be an integer, found %s", fg.PercentageOfActors)
+	}
+	if p > 0 {
+		g = append(g, gates.NewPercentageOfActorsGate(int(p)))
+	}
+
+	p, err = percentage("percentage_of_time", fg.PercentageOfTime)
+	if err != nil {
+		return nil, err
+	}
+	if p > 0 {
+		g = append(g, gates.NewFractionalPercentageOfTimeGate(p))
+	}
+
+	if fg.Schedule != nil {
+		start, err := parseTime("schedule start", fg.Schedule.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTime("schedule end", fg.Schedule.End)
+		if err != nil {
+			return nil, err
+		}
+		if !start.IsZero() && !end.IsZero() && !end.After(start) {
+			return nil, errors.New("schedule must end after it starts")
+		}
+		g = append(g, gates.NewScheduleGate(start, end))
+	}
+
+	if fg.Ramp != nil {
+		r, err := importRamp(*fg.Ramp)
+		if err != nil {
+			return nil, err
+		}
+		g = append(g, r)
+	}
+
+	if len(fg.ExcludedActors) > 0 {
+		if err := validateSet("excluded_actors", fg.ExcludedActors); err != nil {
+			return nil, err
+		}
+		g = append(g, gates.NewExclusionGate(gates.NewSet(fg.ExcludedActors...)))
+	}
+
+	return g, nil
+}
+
+func percentage(k string, v value) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	p, err := strconv.ParseFloat(string(v), 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, errors.Errorf("%s must be a number between 0 and 100, found %q", k, v)
+	}
+	return p, nil
+}
+
+func importRamp(r ramp) (gates.Gate, error) {
+	start, err := parseTime("ramp start", r.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := time.ParseDuration(r.Duration)
+	if err != nil || duration < 0 {
+		return nil, errors.Errorf("ramp duration must be a positive duration, found %q", r.Duration)
+	}
+
+	if r.From < 0 || r.To > 100 || r.From > r.To {
+		return nil, errors.Errorf("ramp percentages must grow between 0 and 100, found %d and %d", r.From, r.To)
+	}
+
+	return gates.NewRampGate(start, duration, r.From, r.To), nil
+}
+
+// parseTime parses times in RFC 3339 format, empty values are zero times.
+func parseTime(k, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s must be a time in RFC 3339 format, found %q", k, v)
+	}
+	return t, nil
+}
+
+// formatTime formats times in RFC 3339 format, in UTC.
+// Zero times are empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func validateSet(k string, values []string) error {
+	for _, v := range values {
+		if v == "" {
+			return errors.Errorf("%s cannot include empty values", k)
+		}
+	}
+	return nil
+}
+
+func sortedValues(s gates.Set) []string {
+	v := make([]string, 0, len(s))
+	for k := range s {
+		v = append(v, k)
+	}
+	sort.Strings(v)
+	return v
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calavera/go-flipper/driver"
+	"github.com/calavera/go-flipper/driver/memory"
+	"github.com/calavera/go-flipper/feature"
+	"github.com/calavera/go-flipper/gates"
+	"github.com/stretchr/testify/require"
+)
+
+// rubyExport is a document exported by the Ruby gem.
+const rubyExport = `{"version":1,"features":{"search":{"boolean":"true","groups":["admins"],"actors":["User;1","User;2"],"percentage_of_actors":"25","percentage_of_time":"12.5"},"checkout":{"boolean":null,"groups":[],"actors":[],"percentage_of_actors":null,"percentage_of_time":null}}}`
+
+func TestExport(t *testing.T) {
+	d := memory.NewDriver()
+	search := feature.NewFeature("search")
+
+	require.NoError(t, d.Enable(search, gates.NewBoolGate(true)))
+	require.NoError(t, d.Enable(search, gates.NewGroupGate(gates.NewSet("admins"))))
+	require.NoError(t, d.Enable(search, gates.NewActorGate(gates.NewSet("User;2", "User;1"))))
+	require.NoError(t, d.Enable(search, gates.NewPercentageOfActorsGate(25)))
+	require.NoError(t, d.Enable(search, gates.NewFractionalPercentageOfTimeGate(12.5)))
+	require.NoError(t, d.Add(feature.NewFeature("checkout")))
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(d, &buf))
+	require.JSONEq(t, rubyExport, buf.String())
+
+	err := Export(struct{ driver.Driver }{d}, &buf)
+	require.Equal(t, driver.ErrListingNotSupported, err)
+}
+
+func TestImport(t *testing.T) {
+	search := feature.NewFeature("search")
+	checkout := feature.NewFeature("checkout")
+
+	t.Run("ruby document", func(t *testing.T) {
+		d := memory.NewDriver()
+		require.NoError(t, Import(d, strings.NewReader(rubyExport), Merge))
+
+		g, err := d.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewBoolGate(true),
+			gates.NewActorGate(gates.NewSet("User;1", "User;2")),
+			gates.NewGroupGate(gates.NewSet("admins")),
+			gates.NewPercentageOfActorsGate(25),
+			gates.NewFractionalPercentageOfTimeGate(12.5),
+		}, g)
+
+		f, err := d.Features()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []feature.Feature{search, checkout}, f)
+
+		var buf bytes.Buffer
+		require.NoError(t, Export(d, &buf))
+		require.JSONEq(t, rubyExport, buf.String())
+	})
+
+	t.Run("json values", func(t *testing.T) {
+		d := memory.NewDriver()
+		doc := `{"version":1,"features":{"search":{"boolean":true,"percentage_of_actors":25,"percentage_of_time":0.5}}}`
+		require.NoError(t, Import(d, strings.NewReader(doc), Merge))
+
+		g, err := d.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewBoolGate(true),
+			gates.NewPercentageOfActorsGate(25),
+			gates.NewFractionalPercentageOfTimeGate(0.5),
+		}, g)
+	})
+
+	t.Run("gates the ruby gem doesn't have", func(t *testing.T) {
+		start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+		d := memory.NewDriver()
+		require.NoError(t, d.Enable(search, gates.NewScheduleGate(start, time.Time{})))
+		require.NoError(t, d.Enable(search, gates.NewRampGate(start, 72*time.Hour, 1, 100)))
+		require.NoError(t, d.Enable(search, gates.NewExclusionGate(gates.NewSet("User;2", "User;1"))))
+
+		var buf bytes.Buffer
+		require.NoError(t, Export(d, &buf))
+		require.Contains(t, buf.String(), `"schedule":{"start":"2018-11-01T00:00:00Z"}`)
+		require.Contains(t, buf.String(), `"ramp":{"start":"2018-11-01T00:00:00Z","duration":"72h0m0s","from":1,"to":100}`)
+		require.Contains(t, buf.String(), `"excluded_actors":["User;1","User;2"]`)
+
+		imported := memory.NewDriver()
+		require.NoError(t, imported.Enable(search, gates.NewScheduleGate(time.Time{}, start)))
+		require.NoError(t, imported.Enable(search, gates.NewExclusionGate(gates.NewSet("User;3"))))
+		require.NoError(t, Import(imported, &buf, Replace))
+
+		g, err := imported.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewScheduleGate(start, time.Time{}),
+			gates.NewRampGate(start, 72*time.Hour, 1, 100),
+			gates.NewExclusionGate(gates.NewSet("User;1", "User;2")),
+		}, g)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		d := memory.NewDriver()
+		require.NoError(t, d.Enable(search, gates.NewActorGate(gates.NewSet("User;3"))))
+		require.NoError(t, d.Enable(search, gates.NewPercentageOfTimeGate(50)))
+		require.NoError(t, d.Enable(feature.NewFeature("other"), gates.NewBoolGate(true)))
+
+		doc := `{"version":1,"features":{"search":{"actors":["User;1"],"percentage_of_actors":"10"}}}`
+		require.NoError(t, Import(d, strings.NewReader(doc), Merge))
+
+		g, err := d.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewActorGate(gates.NewSet("User;1", "User;3")),
+			gates.NewPercentageOfActorsGate(10),
+			gates.NewPercentageOfTimeGate(50),
+		}, g)
+
+		f, err := d.Features()
+		require.NoError(t, err)
+		require.Len(t, f, 2)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		d := memory.NewDriver()
+		require.NoError(t, d.Enable(search, gates.NewActorGate(gates.NewSet("User;3"))))
+		require.NoError(t, d.Enable(search, gates.NewPercentageOfTimeGate(50)))
+		require.NoError(t, d.Enable(feature.NewFeature("other"), gates.NewBoolGate(true)))
+
+		doc := `{"version":1,"features":{"search":{"actors":["User;1"],"percentage_of_actors":"10"}}}`
+		require.NoError(t, Import(d, strings.NewReader(doc), Replace))
+
+		g, err := d.Get(search, allKeys)
+		require.NoError(t, err)
+		require.Equal(t, []gates.Gate{
+			gates.NewActorGate(gates.NewSet("User;1")),
+			gates.NewPercentageOfActorsGate(10),
+		}, g)
+
+		f, err := d.Features()
+		require.NoError(t, err)
+		require.Equal(t, []feature.Feature{search}, f)
+
+		g, err = d.Get(feature.NewFeature("other"), allKeys)
+		require.NoError(t, err)
+		require.Len(t, g, 0)
+	})
+
+	t.Run("invalid documents", func(t *testing.T) {
+		tests := map[string]string{
+			`{"version":1,"features":`:                                                                                       "invalid export document",
+			`{"version":2,"features":{}}`:                                                                                    "unsupported export version: 2",
+			`{"version":1,"features":{"search":{"boolean":"yes"}}}`:                                                          `invalid feature "search": boolean must be`,
+			`{"version":1,"features":{"search":{"actors":[""]}}}`:                                                            `invalid feature "search": actors cannot include empty values`,
+			`{"version":1,"features":{"search":{"percentage_of_actors":"12.5"}}}`:                                            `invalid feature "search": percentage_of_actors must be an integer`,
+			`{"version":1,"features":{"search":{"percentage_of_time":"lots"}}}`:                                              `invalid feature "search": percentage_of_time must be a number`,
+			`{"version":1,"features":{"a":{},"search":{"percentage_of_time":101}}}`:                                          `invalid feature "search": percentage_of_time must be a number`,
+			`{"version":1,"features":{"search":{"boolean":"true"},"other":{"groups":1}}}`:                                    "invalid export document",
+			`{"version":1,"features":{"search":{"excluded_actors":[""]}}}`:                                                   `invalid feature "search": excluded_actors cannot include empty values`,
+			`{"version":1,"features":{"search":{"ramp":{"duration":"soon","from":0,"to":10}}}}`:                              `invalid feature "search": ramp duration must be a positive duration`,
+			`{"version":1,"features":{"search":{"ramp":{"duration":"1h","from":50,"to":10}}}}`:                               `invalid feature "search": ramp percentages must grow between 0 and 100`,
+			`{"version":1,"features":{"search":{"schedule":{"start":"tomorrow"}}}}`:                                          `invalid feature "search": schedule start must be a time`,
+			`{"version":1,"features":{"search":{"schedule":{"start":"2018-11-02T00:00:00Z","end":"2018-11-01T00:00:00Z"}}}}`: `invalid feature "search": schedule must end after it starts`,
+		}
+
+		for doc, expected := range tests {
+			d := memory.NewDriver()
+			err := Import(d, strings.NewReader(doc), Replace)
+			require.Error(t, err, doc)
+			require.Contains(t, err.Error(), expected)
+
+			f, err := d.Features()
+			require.NoError(t, err)
+			require.Len(t, f, 0)
+		}
+
+		err := Import(struct{ driver.Driver }{memory.NewDriver()}, strings.NewReader(rubyExport), Merge)
+		require.Equal(t, driver.ErrListingNotSupported, err)
+	})
+}
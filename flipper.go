@@ -8,16 +8,19 @@ import (
 
 // NewClient initializes a Client with a store driver.
 // The Driver must be registered before creating a new client.
-// The configuration is mapped to the driver requirements before the client is initialized.
-func NewClient(driverName string, config map[string]interface{}) (*client.Client, error) {
-	a := driver.Get(driverName)
-	if a == nil {
+// Every client gets its own driver, created by the registered factory
+// with the configuration mapped to the driver requirements.
+// The options are passed to client.NewClient.
+func NewClient(driverName string, config map[string]interface{}, opts ...client.Option) (*client.Client, error) {
+	factory := driver.Lookup(driverName)
+	if factory == nil {
 		return nil, errors.Errorf("Flipper driver not registered with name: %s", driverName)
 	}
 
-	if err := a.Configure(config); err != nil {
+	a, err := factory(config)
+	if err != nil {
 		return nil, errors.Wrapf(err, "Configuration error for Flipper driver %s", driverName)
 	}
 
-	return client.NewClient(a), nil
+	return client.NewClient(a, opts...), nil
 }
@@ -169,3 +169,21 @@ func TestNewClient(t *testing.T) {
 	_, err := flipper.NewClient("memory", nil)
 	require.NoError(t, err)
 }
+
+func TestNewClient_Independent(t *testing.T) {
+	a, err := flipper.NewClient("memory", nil)
+	require.NoError(t, err)
+	b, err := flipper.NewClient("memory", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Enable("feature"))
+
+	enabled, err := b.IsEnabled("feature")
+	require.NoError(t, err)
+	require.False(t, enabled)
+}
+
+func TestNewClient_NotRegistered(t *testing.T) {
+	_, err := flipper.NewClient("unknown", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package gates
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time to the gates that depend on it,
+// like ScheduleGate.
+// Implementations must be safe for concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to use ordinary functions as Clock.
+type ClockFunc func() time.Time
+
+// Now calls f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+var (
+	clockMu      sync.RWMutex
+	defaultClock Clock = ClockFunc(time.Now)
+)
+
+// SetClock changes the Clock used by every gate that doesn't have its own clock.
+// Passing nil restores the default clock, time.Now.
+// This is useful to check features at a given time in tests.
+// It's safe to call it while features are being checked.
+func SetClock(c Clock) {
+	if c == nil {
+		c = ClockFunc(time.Now)
+	}
+
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	defaultClock = c
+}
+
+func now(c Clock) time.Time {
+	if c == nil {
+		clockMu.RLock()
+		c = defaultClock
+		clockMu.RUnlock()
+	}
+	return c.Now()
+}
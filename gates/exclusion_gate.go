@@ -0,0 +1,43 @@
+package gates
+
+import (
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/feature"
+)
+
+// ExclusionGate is a gate that keeps a feature closed for a set of actors,
+// even when other gates are open for them.
+// It never opens a feature by itself, clients check it before any other gate.
+type ExclusionGate struct {
+	value Set
+}
+
+// NewExclusionGate initializes an ExclusionGate with a set of ids.
+func NewExclusionGate(set Set) ExclusionGate {
+	return ExclusionGate{set}
+}
+
+// Key returns the GateKey for an ExclusionGate gate.
+func (ExclusionGate) Key() GateKey {
+	return ExclusionGateKey
+}
+
+// IsOpen check if the gate is open for an feature and an actor.
+// It's always closed, use Excludes to know if an actor is excluded.
+func (ExclusionGate) IsOpen(f feature.Feature, a actor.Actor) bool {
+	return false
+}
+
+// Excludes checks if a feature must be closed for an actor,
+// using the actor set.
+// This satisfies the ExclusionGateType interface.
+func (g ExclusionGate) Excludes(f feature.Feature, a actor.Actor) bool {
+	_, ok := g.value[a.FlipperID()]
+	return ok
+}
+
+// SetValue returns the set of actors excluded by the gate.
+// This satisfies the SetGateType interface.
+func (g ExclusionGate) SetValue() Set {
+	return g.value
+}
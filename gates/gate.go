@@ -1,6 +1,8 @@
 package gates
 
 import (
+	"time"
+
 	"github.com/calavera/go-flipper/actor"
 	"github.com/calavera/go-flipper/feature"
 )
@@ -19,6 +21,12 @@ const (
 	PercentageOfActorsGateKey GateKey = "percentage_of_actors"
 	// PercentageOfTimeGateKey is the key for a PercentageOfTimeGate
 	PercentageOfTimeGateKey GateKey = "percentage_of_time"
+	// ScheduleGateKey is the key for a ScheduleGate
+	ScheduleGateKey GateKey = "schedule"
+	// RampGateKey is the key for a RampGate
+	RampGateKey GateKey = "ramp"
+	// ExclusionGateKey is the key for an ExclusionGate
+	ExclusionGateKey GateKey = "excluded_actors"
 )
 
 // Set is a key set.
@@ -43,11 +51,42 @@ type IntGateType interface {
 	IntValue() int
 }
 
+// FloatGateType represents a gate that uses float values.
+type FloatGateType interface {
+	FloatValue() float64
+}
+
 // SetGateType represents a gate that uses set values.
 type SetGateType interface {
 	SetValue() Set
 }
 
+// TimeRangeGateType represents a gate that uses a time range.
+// Zero times mean that the range is open on that side.
+type TimeRangeGateType interface {
+	TimeRange() (start, end time.Time)
+}
+
+// RampGateType represents a gate that uses a percentage that changes over time.
+type RampGateType interface {
+	Ramp() (start time.Time, duration time.Duration, from, to int)
+}
+
+// ExclusionGateType represents a gate that closes a feature for some actors,
+// regardless of the other gates.
+type ExclusionGateType interface {
+	Excludes(f feature.Feature, a actor.Actor) bool
+}
+
+// NumberValue returns the value of a gate that uses numbers.
+// It keeps the decimals for gates that satisfy the FloatGateType interface.
+func NumberValue(g IntGateType) float64 {
+	if f, ok := g.(FloatGateType); ok {
+		return f.FloatValue()
+	}
+	return float64(g.IntValue())
+}
+
 func NewSet(values ...string) Set {
 	s := Set{}
 	for _, v := range values {
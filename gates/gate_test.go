@@ -1,7 +1,12 @@
 package gates
 
 import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/calavera/go-flipper/actor/testhelpers"
 	"github.com/calavera/go-flipper/feature"
@@ -13,6 +18,258 @@ func TestPercentageOfActorsGate(t *testing.T) {
 	a := testhelpers.Actor{"58474832756cfb0015870214"}
 	p := NewPercentageOfActorsGate(30)
 
-	assert.Equal(t, uint32(28792), p.checksum(a))
+	assert.Equal(t, uint32(28792), checksum(a))
 	assert.True(t, p.IsOpen(f, a))
+
+	// fractional percentages use the same buckets as the Ruby gem.
+	bucket := float64(checksum(feature.NewFeaturedActor(f, a))) / float64(scalingFactor)
+	assert.True(t, NewFractionalPercentageOfActorsGate(bucket+0.0015).IsOpen(f, a))
+	assert.False(t, NewFractionalPercentageOfActorsGate(bucket).IsOpen(f, a))
+	assert.Equal(t, 12.5, NumberValue(NewFractionalPercentageOfActorsGate(12.5)))
+}
+
+func TestPercentageOfTimeGate(t *testing.T) {
+	f := feature.NewFeature("test")
+	a := testhelpers.Actor{ID: "58474832756cfb0015870214"}
+
+	t.Run("boundaries", func(t *testing.T) {
+		low := RandomFunc(func() float64 { return 0 })
+		high := RandomFunc(func() float64 { return 0.9999 })
+
+		assert.False(t, NewPercentageOfTimeGate(0).WithRandomSource(low).IsOpen(f, a))
+		assert.True(t, NewPercentageOfTimeGate(1).WithRandomSource(low).IsOpen(f, a))
+		assert.True(t, NewPercentageOfTimeGate(100).WithRandomSource(high).IsOpen(f, a))
+		assert.False(t, NewPercentageOfTimeGate(99).WithRandomSource(high).IsOpen(f, a))
+
+		half := RandomFunc(func() float64 { return 0.125 })
+		assert.False(t, NewFractionalPercentageOfTimeGate(12.5).WithRandomSource(half).IsOpen(f, a))
+		assert.True(t, NewFractionalPercentageOfTimeGate(12.6).WithRandomSource(half).IsOpen(f, a))
+	})
+
+	t.Run("values", func(t *testing.T) {
+		g := NewFractionalPercentageOfTimeGate(12.5)
+		assert.Equal(t, 12, g.IntValue())
+		assert.Equal(t, 12.5, g.FloatValue())
+		assert.Equal(t, 12.5, NumberValue(g))
+		assert.Equal(t, float64(30), NumberValue(NewPercentageOfActorsGate(30)))
+	})
+
+	t.Run("distribution", func(t *testing.T) {
+		const checks = 100000
+		r := rand.New(rand.NewSource(42))
+
+		for _, p := range []float64{0, 0.5, 12.5, 50, 90, 100} {
+			g := NewFractionalPercentageOfTimeGate(p).WithRandomSource(RandomFunc(r.Float64))
+
+			open := 0
+			for i := 0; i < checks; i++ {
+				if g.IsOpen(f, a) {
+					open++
+				}
+			}
+
+			assert.InDelta(t, p, float64(open)*100/checks, 0.5, "percentage %v", p)
+		}
+	})
+
+	t.Run("default source is safe for concurrent use", func(t *testing.T) {
+		g := NewPercentageOfTimeGate(50)
+
+		var wg sync.WaitGroup
+		var open int64
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10000; j++ {
+					if g.IsOpen(f, a) {
+						atomic.AddInt64(&open, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.InDelta(t, 50, float64(open)*100/80000, 1)
+	})
+
+	t.Run("package source", func(t *testing.T) {
+		SetRandomSource(RandomFunc(func() float64 { return 0.3 }))
+		defer SetRandomSource(nil)
+
+		assert.False(t, NewPercentageOfTimeGate(30).IsOpen(f, a))
+		assert.True(t, NewPercentageOfTimeGate(31).IsOpen(f, a))
+	})
+
+	t.Run("concurrent source changes", func(t *testing.T) {
+		g := NewPercentageOfTimeGate(50)
+		defer SetRandomSource(nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				SetRandomSource(RandomFunc(func() float64 { return 0 }))
+			}()
+			go func() {
+				defer wg.Done()
+				g.IsOpen(f, a)
+			}()
+		}
+		wg.Wait()
+		assert.True(t, g.IsOpen(f, a))
+	})
+}
+
+func TestScheduleGate(t *testing.T) {
+	f := feature.NewFeature("test")
+	a := testhelpers.Actor{ID: "58474832756cfb0015870214"}
+
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2018, 11, 2, 0, 0, 0, 0, time.UTC)
+	at := func(t time.Time) Clock {
+		return ClockFunc(func() time.Time { return t })
+	}
+
+	t.Run("time window", func(t *testing.T) {
+		g := NewScheduleGate(start, end)
+		assert.False(t, g.WithClock(at(start.Add(-time.Second))).IsOpen(f, a))
+		assert.True(t, g.WithClock(at(start)).IsOpen(f, a))
+		assert.True(t, g.WithClock(at(end.Add(-time.Second))).IsOpen(f, a))
+		assert.False(t, g.WithClock(at(end)).IsOpen(f, a))
+	})
+
+	t.Run("open ended", func(t *testing.T) {
+		g := NewScheduleGate(time.Time{}, end)
+		assert.True(t, g.WithClock(at(time.Time{})).IsOpen(f, a))
+		assert.False(t, g.WithClock(at(end)).IsOpen(f, a))
+
+		g = NewScheduleGate(start, time.Time{})
+		assert.False(t, g.WithClock(at(start.Add(-time.Second))).IsOpen(f, a))
+		assert.True(t, g.WithClock(at(end.AddDate(10, 0, 0))).IsOpen(f, a))
+	})
+
+	t.Run("default clock", func(t *testing.T) {
+		g := NewScheduleGate(start, end)
+		assert.False(t, g.IsOpen(f, a))
+
+		SetClock(at(start))
+		defer SetClock(nil)
+		assert.True(t, g.IsOpen(f, a))
+	})
+
+	t.Run("concurrent clock changes", func(t *testing.T) {
+		g := NewScheduleGate(start, end)
+		defer SetClock(nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				SetClock(at(start))
+			}()
+			go func() {
+				defer wg.Done()
+				g.IsOpen(f, a)
+			}()
+		}
+		wg.Wait()
+		assert.True(t, g.IsOpen(f, a))
+	})
+
+	t.Run("values", func(t *testing.T) {
+		local := time.FixedZone("UTC-3", -3*60*60)
+		g := NewScheduleGate(start.In(local), end.In(local))
+
+		s, e := g.TimeRange()
+		assert.Equal(t, start, s)
+		assert.Equal(t, end, e)
+		assert.Equal(t, ScheduleGateKey, g.Key())
+	})
+}
+
+func TestRampGate(t *testing.T) {
+	f := feature.NewFeature("test")
+	start := time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+	at := func(t time.Time) Clock {
+		return ClockFunc(func() time.Time { return t })
+	}
+
+	t.Run("percentage", func(t *testing.T) {
+		g := NewRampGate(start, 10*time.Hour, 10, 60)
+		assert.Equal(t, float64(0), g.WithClock(at(start.Add(-time.Hour))).Percentage())
+		assert.Equal(t, float64(10), g.WithClock(at(start)).Percentage())
+		assert.Equal(t, float64(35), g.WithClock(at(start.Add(5*time.Hour))).Percentage())
+		assert.Equal(t, float64(60), g.WithClock(at(start.Add(10*time.Hour))).Percentage())
+		assert.Equal(t, float64(60), g.WithClock(at(start.AddDate(1, 0, 0))).Percentage())
+
+		g = NewRampGate(start, 0, 0, 100)
+		assert.Equal(t, float64(0), g.WithClock(at(start.Add(-time.Second))).Percentage())
+		assert.Equal(t, float64(100), g.WithClock(at(start)).Percentage())
+	})
+
+	t.Run("closed before the start", func(t *testing.T) {
+		g := NewRampGate(start, time.Hour, 50, 100).WithClock(at(start.Add(-time.Nanosecond)))
+		for i := 0; i < 100; i++ {
+			assert.False(t, g.IsOpen(f, testhelpers.Actor{ID: strconv.Itoa(i)}))
+		}
+	})
+
+	t.Run("same buckets as percentage of actors", func(t *testing.T) {
+		g := NewRampGate(start, time.Hour, 20, 20).WithClock(at(start))
+		p := NewPercentageOfActorsGate(20)
+
+		for i := 0; i < 1000; i++ {
+			a := testhelpers.Actor{ID: strconv.Itoa(i)}
+			assert.Equal(t, p.IsOpen(f, a), g.IsOpen(f, a))
+		}
+	})
+
+	t.Run("enabled actors stay enabled", func(t *testing.T) {
+		g := NewRampGate(start, 100*time.Minute, 0, 100)
+
+		enabled := make(map[string]bool)
+		for m := 0; m <= 100; m += 5 {
+			c := g.WithClock(at(start.Add(time.Duration(m) * time.Minute)))
+
+			open := 0
+			for i := 0; i < 1000; i++ {
+				a := testhelpers.Actor{ID: strconv.Itoa(i)}
+				if c.IsOpen(f, a) {
+					open++
+					enabled[a.ID] = true
+				} else {
+					assert.False(t, enabled[a.ID], "actor %s was disabled at minute %d", a.ID, m)
+				}
+			}
+			assert.InDelta(t, m*10, open, 50)
+		}
+		assert.Len(t, enabled, 1000)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		local := time.FixedZone("UTC-3", -3*60*60)
+		g := NewRampGate(start.In(local), time.Hour, 5, 50)
+
+		s, d, from, to := g.Ramp()
+		assert.Equal(t, start, s)
+		assert.Equal(t, time.Hour, d)
+		assert.Equal(t, 5, from)
+		assert.Equal(t, 50, to)
+		assert.Equal(t, RampGateKey, g.Key())
+	})
+}
+
+func TestExclusionGate(t *testing.T) {
+	f := feature.NewFeature("test")
+	g := NewExclusionGate(NewSet("User;1"))
+
+	assert.True(t, g.Excludes(f, testhelpers.Actor{ID: "User;1"}))
+	assert.False(t, g.Excludes(f, testhelpers.Actor{ID: "User;2"}))
+
+	assert.False(t, g.IsOpen(f, testhelpers.Actor{ID: "User;1"}))
+	assert.False(t, g.IsOpen(f, testhelpers.Actor{ID: "User;2"}))
+	assert.Equal(t, ExclusionGateKey, g.Key())
 }
@@ -49,3 +49,9 @@ func (g GroupGate) SetValue() Set {
 func RegisterGroup(name string, f GroupFunc) {
 	registry[name] = f
 }
+
+// IsGroupRegistered checks if a group name is associated with a function.
+func IsGroupRegistered(name string) bool {
+	_, ok := registry[name]
+	return ok
+}
@@ -8,15 +8,22 @@ import (
 )
 
 // PercentageOfActorsGate is a gate that's open only for a percentage of the actors checked.
-// The percentage can be any value between 0 and 100.
+// The percentage can be any value between 0 and 100,
+// including fractional values like 0.5.
 type PercentageOfActorsGate struct {
-	value uint32
+	value float64
 }
 
 // NewPercentageOfActorsGate initializes a PercentageOfActorsGate gate
 // with a given percentage.
 func NewPercentageOfActorsGate(percentage int) PercentageOfActorsGate {
-	return PercentageOfActorsGate{uint32(percentage)}
+	return NewFractionalPercentageOfActorsGate(float64(percentage))
+}
+
+// NewFractionalPercentageOfActorsGate initializes a PercentageOfActorsGate gate
+// with a percentage that can include decimals, like the Ruby gem supports.
+func NewFractionalPercentageOfActorsGate(percentage float64) PercentageOfActorsGate {
+	return PercentageOfActorsGate{percentage}
 }
 
 // Key returns the GateKey for a PercentageOfActorsGate gate.
@@ -27,16 +34,24 @@ func (PercentageOfActorsGate) Key() GateKey {
 // IsOpen check if the gate is open for an feature and an actor.
 // It calculates the likeliness of being open by its percentage.
 func (g PercentageOfActorsGate) IsOpen(f feature.Feature, a actor.Actor) bool {
-	return g.checksum(feature.NewFeaturedActor(f, a)) < (g.value * scalingFactor)
+	return checksum(feature.NewFeaturedActor(f, a)) < uint32(g.value*float64(scalingFactor))
 }
 
-// IntValue returns the gate's percentage as an int.
+// IntValue returns the gate's percentage as an int, without decimals.
 // This satisfies the IntGateType interface.
 func (g PercentageOfActorsGate) IntValue() int {
 	return int(g.value)
 }
 
-func (g PercentageOfActorsGate) checksum(a actor.Actor) uint32 {
+// FloatValue returns the gate's percentage, including decimals.
+// This satisfies the FloatGateType interface.
+func (g PercentageOfActorsGate) FloatValue() float64 {
+	return g.value
+}
+
+// checksum puts an actor in one of the buckets used by the gates
+// that open for a percentage of actors.
+func checksum(a actor.Actor) uint32 {
 	p := crc32.ChecksumIEEE([]byte(a.FlipperID()))
 	return p % (scalingFactor * rangeFactor)
 }
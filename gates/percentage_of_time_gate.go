@@ -2,24 +2,72 @@ package gates
 
 import (
 	"math/rand"
-	"time"
+	"sync"
 
 	"github.com/calavera/go-flipper/actor"
 	"github.com/calavera/go-flipper/feature"
 )
 
-var seed = rand.New(rand.NewSource(time.Now().UnixNano()))
+// RandomSource generates the random numbers used by PercentageOfTimeGate.
+// Implementations must be safe for concurrent use.
+type RandomSource interface {
+	// Float64 returns a pseudo-random number in [0.0, 1.0).
+	Float64() float64
+}
+
+// RandomFunc is an adapter to use ordinary functions as RandomSource.
+type RandomFunc func() float64
+
+// Float64 calls f().
+func (f RandomFunc) Float64() float64 {
+	return f()
+}
+
+// defaultRandom uses the top level math/rand functions,
+// which are safe for concurrent use.
+var (
+	randomMu      sync.RWMutex
+	defaultRandom RandomSource = RandomFunc(rand.Float64)
+)
+
+// SetRandomSource changes the RandomSource used by every PercentageOfTimeGate
+// that doesn't have its own source. Passing nil restores the default source.
+// This is useful to make checks deterministic in tests.
+// It's safe to call it while features are being checked.
+func SetRandomSource(r RandomSource) {
+	if r == nil {
+		r = RandomFunc(rand.Float64)
+	}
+
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	defaultRandom = r
+}
 
 // PercentageOfTimeGate is a gate that's open only a percentage of times.
-// The percentage can be any value between 0 and 100.
+// The percentage can be any value between 0 and 100,
+// including fractional values like 0.5.
 type PercentageOfTimeGate struct {
-	value uint32
+	value  float64
+	random RandomSource
 }
 
 // NewPercentageOfTimeGate initializes a PercentageOfTimeGate gate
 // with a given percentage.
 func NewPercentageOfTimeGate(percentage int) PercentageOfTimeGate {
-	return PercentageOfTimeGate{uint32(percentage)}
+	return NewFractionalPercentageOfTimeGate(float64(percentage))
+}
+
+// NewFractionalPercentageOfTimeGate initializes a PercentageOfTimeGate gate
+// with a percentage that can include decimals, like the Ruby gem supports.
+func NewFractionalPercentageOfTimeGate(percentage float64) PercentageOfTimeGate {
+	return PercentageOfTimeGate{value: percentage}
+}
+
+// WithRandomSource returns a copy of the gate that uses a given RandomSource.
+func (g PercentageOfTimeGate) WithRandomSource(r RandomSource) PercentageOfTimeGate {
+	g.random = r
+	return g
 }
 
 // Key returns the GateKey for a PercentageOfTimeGate gate.
@@ -28,14 +76,26 @@ func (PercentageOfTimeGate) Key() GateKey {
 }
 
 // IsOpen check if the gate is open for an feature and an actor.
-// It calculates the likeliness of being open by its percentage.
+// It's open with a probability equal to its percentage.
 func (g PercentageOfTimeGate) IsOpen(f feature.Feature, a actor.Actor) bool {
-	r := seed.Uint32()
-	return r < (g.value / rangeFactor)
+	r := g.random
+	if r == nil {
+		randomMu.RLock()
+		r = defaultRandom
+		randomMu.RUnlock()
+	}
+	return r.Float64()*float64(rangeFactor) < g.value
 }
 
 // IntValue returns the gate's percentage as an int.
+// Decimals are truncated.
 // This satisfies the IntGateType interface.
 func (g PercentageOfTimeGate) IntValue() int {
 	return int(g.value)
 }
+
+// FloatValue returns the gate's percentage, including decimals.
+// This satisfies the FloatGateType interface.
+func (g PercentageOfTimeGate) FloatValue() float64 {
+	return g.value
+}
@@ -0,0 +1,71 @@
+package gates
+
+import (
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/feature"
+)
+
+// RampGate is a gate that's open for a percentage of the actors checked
+// that grows over time. The percentage goes from an initial value at the
+// start time to a final value when the duration is over, linearly.
+// The gate is closed for every actor before the start time.
+// Actors are put in buckets like in PercentageOfActorsGate, so the actors
+// that are enabled stay enabled while the percentage grows.
+type RampGate struct {
+	start    time.Time
+	duration time.Duration
+	from     int
+	to       int
+	clock    Clock
+}
+
+// NewRampGate initializes a RampGate gate that grows from one percentage
+// to another during a period of time. Times are kept in UTC.
+func NewRampGate(start time.Time, duration time.Duration, from, to int) RampGate {
+	return RampGate{start: start.UTC(), duration: duration, from: from, to: to}
+}
+
+// WithClock returns a copy of the gate that uses a given Clock.
+func (g RampGate) WithClock(c Clock) RampGate {
+	g.clock = c
+	return g
+}
+
+// Key returns the GateKey for a RampGate gate.
+func (RampGate) Key() GateKey {
+	return RampGateKey
+}
+
+// IsOpen check if the gate is open for an feature and an actor.
+// It calculates the likeliness of being open by its current percentage.
+func (g RampGate) IsOpen(f feature.Feature, a actor.Actor) bool {
+	return checksum(feature.NewFeaturedActor(f, a)) < g.threshold(now(g.clock))
+}
+
+// Percentage returns the percentage of actors the gate is open for right now.
+func (g RampGate) Percentage() float64 {
+	return float64(g.threshold(now(g.clock))) / float64(scalingFactor)
+}
+
+// Ramp returns the start time, the duration and the initial and final percentages of the gate.
+// This satisfies the RampGateType interface.
+func (g RampGate) Ramp() (time.Time, time.Duration, int, int) {
+	return g.start, g.duration, g.from, g.to
+}
+
+// threshold returns the scaled percentage of the gate at a given time,
+// zero before the start time.
+func (g RampGate) threshold(t time.Time) uint32 {
+	elapsed := t.Sub(g.start)
+	switch {
+	case elapsed < 0:
+		return 0
+	case elapsed >= g.duration:
+		return uint32(g.to) * scalingFactor
+	}
+
+	progress := float64(elapsed) / float64(g.duration)
+	return uint32(float64(g.from*int(scalingFactor)) + float64((g.to-g.from)*int(scalingFactor))*progress)
+}
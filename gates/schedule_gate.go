@@ -0,0 +1,48 @@
+package gates
+
+import (
+	"time"
+
+	"github.com/calavera/go-flipper/actor"
+	"github.com/calavera/go-flipper/feature"
+)
+
+// ScheduleGate is a gate that's open during a time window.
+// It's open from its start time, included, until its end time, excluded.
+// A zero start time opens the gate right away,
+// and a zero end time keeps it open forever.
+type ScheduleGate struct {
+	start time.Time
+	end   time.Time
+	clock Clock
+}
+
+// NewScheduleGate initializes a ScheduleGate gate with a time window.
+// Times are kept in UTC.
+func NewScheduleGate(start, end time.Time) ScheduleGate {
+	return ScheduleGate{start: start.UTC(), end: end.UTC()}
+}
+
+// WithClock returns a copy of the gate that uses a given Clock.
+func (g ScheduleGate) WithClock(c Clock) ScheduleGate {
+	g.clock = c
+	return g
+}
+
+// Key returns the GateKey for a ScheduleGate gate.
+func (ScheduleGate) Key() GateKey {
+	return ScheduleGateKey
+}
+
+// IsOpen check if the gate is open for an feature and an actor.
+// It's open when the current time is in the gate's time window.
+func (g ScheduleGate) IsOpen(f feature.Feature, a actor.Actor) bool {
+	t := now(g.clock)
+	return (g.start.IsZero() || !t.Before(g.start)) && (g.end.IsZero() || t.Before(g.end))
+}
+
+// TimeRange returns the start and end times of the gate's time window.
+// This satisfies the TimeRangeGateType interface.
+func (g ScheduleGate) TimeRange() (time.Time, time.Time) {
+	return g.start, g.end
+}